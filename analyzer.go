@@ -0,0 +1,103 @@
+package stored
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Analyzer converts text into the list of terms stored inside search index,
+// same analyzer is used to split the search query
+type Analyzer interface {
+	Analyze(text string) []string
+}
+
+// TextAnalyzer is configurable analyzer: text is split by Tokenizer, each token passes
+// Normalizers in order, tokens found in StopWords are skipped and the rest are stemmed
+type TextAnalyzer struct {
+	Tokenizer   func(text string) []string
+	Normalizers []func(token string) string
+	StopWords   map[string]bool
+	Stemmer     func(token string) string
+}
+
+// Analyze implements Analyzer interface
+func (a *TextAnalyzer) Analyze(text string) (terms []string) {
+	tokenizer := a.Tokenizer
+	if tokenizer == nil {
+		tokenizer = TokenizeUnicode
+	}
+	for _, token := range tokenizer(text) {
+		for _, normalize := range a.Normalizers {
+			token = normalize(token)
+		}
+		if token == "" || a.StopWords[token] {
+			continue
+		}
+		if a.Stemmer != nil {
+			token = a.Stemmer(token)
+		}
+		terms = append(terms, token)
+	}
+	return
+}
+
+// AnalyzerDefault is analyzer used by search index unless other one is set with IndexOption
+var AnalyzerDefault Analyzer = &TextAnalyzer{Tokenizer: searchSplit}
+
+// AnalyzerEnglish splits text by any unicode punctuation, folds diacritics,
+// removes common english words and performs Porter2 (Snowball) stemming
+var AnalyzerEnglish Analyzer = &TextAnalyzer{
+	Normalizers: []func(string) string{strings.ToLower, FoldDiacritics},
+	StopWords:   stopWords(englishStopWords),
+	Stemmer:     StemEnglish,
+}
+
+// AnalyzerRussian splits text by any unicode punctuation, replaces «ё» with «е»,
+// removes common russian words and performs Snowball stemming
+var AnalyzerRussian Analyzer = &TextAnalyzer{
+	Normalizers: []func(string) string{strings.ToLower, foldRussian},
+	StopWords:   stopWords(russianStopWords),
+	Stemmer:     StemRussian,
+}
+
+// TokenizeUnicode splits text by any character which is not letter or digit
+func TokenizeUnicode(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+// FoldDiacritics removes diacritical marks, so «café» will become «cafe»
+func FoldDiacritics(token string) string {
+	decomposed := norm.NFD.String(token)
+	folded := make([]rune, 0, len(decomposed))
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		folded = append(folded, r)
+	}
+	return norm.NFC.String(string(folded))
+}
+
+func foldRussian(token string) string {
+	return strings.ReplaceAll(token, "ё", "е")
+}
+
+func stopWords(words string) map[string]bool {
+	res := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		res[word] = true
+	}
+	return res
+}
+
+const englishStopWords = `a an and are as at be but by for if in into is it no not of on or such
+that the their then there these they this to was will with`
+
+const russianStopWords = `и в во не что он на я с со как а то все она так его но да ты к у же вы за бы
+по только ее мне было вот от меня еще нет о из ему теперь когда даже ну вдруг ли если уже или ни быть
+был него до вас нибудь опять уж вам ведь там потом себя ничего ей может они тут где есть надо ней для
+мы тебя их чем была сам чтоб без будто чего раз тоже себе под будет ж тогда кто этот`
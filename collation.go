@@ -0,0 +1,71 @@
+package stored
+
+import (
+	"strings"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Collation describes how string values of the field are compared inside indexes
+type Collation int
+
+const (
+	// CollationBinary compares strings byte by byte, this is default collation
+	CollationBinary Collation = iota
+	// CollationNormalized compares strings after unicode NFKC normalization
+	CollationNormalized
+	// CollationCaseInsensitive compares strings after unicode NFKC normalization and case folding,
+	// so «John@x.com» and «john@x.com» will be the same value
+	CollationCaseInsensitive
+)
+
+var collationFolder = cases.Fold()
+
+// collate will return string in the form it should be stored inside the index
+func (c Collation) collate(str string) string {
+	switch c {
+	case CollationNormalized:
+		return norm.NFKC.String(str)
+	case CollationCaseInsensitive:
+		return norm.NFKC.String(collationFolder.String(str))
+	}
+	return str
+}
+
+// descendingEncode inverts packed element, so bigger values will go first in the index.
+// Packed tuple elements are prefix free, so inverted bytes are ordered in opposite way
+func descendingEncode(element tuple.TupleElement) []byte {
+	packed := tuple.Tuple{element}.Pack()
+	for k := range packed {
+		packed[k] = ^packed[k]
+	}
+	return packed
+}
+
+// descendingDecode will return original element from inverted form
+func descendingDecode(data []byte) (tuple.TupleElement, error) {
+	packed := make([]byte, len(data))
+	for k := range data {
+		packed[k] = ^data[k]
+	}
+	t, err := tuple.Unpack(packed)
+	if err != nil {
+		return nil, err
+	}
+	if len(t) != 1 {
+		return nil, ErrDataCorrupt
+	}
+	return t[0], nil
+}
+
+func parseCollation(name string) (Collation, bool) {
+	switch strings.ToLower(name) {
+	case "nocase":
+		return CollationCaseInsensitive, true
+	case "normalize":
+		return CollationNormalized, true
+	}
+	return CollationBinary, false
+}
@@ -2,6 +2,7 @@ package stored
 
 import (
 	"bytes"
+	"context"
 	"hash/fnv"
 	"math/rand"
 	"net"
@@ -10,15 +11,26 @@ import (
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/directory"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
 )
 
+// sharedDir is reserved name of the directory holding data shared between objects, like unique
+// namespaces and search indexes, so it could not collide with directories of objects
+const sharedDir = "_shared"
+
 // Directory is wrapper around foundation db directories, main entry point for working with STORED
 type Directory struct {
-	Name     string
-	Cluster  *Cluster
-	Subspace directory.DirectorySubspace
-	objects  map[string]*Object
-	mux      sync.Mutex
+	Name       string
+	Cluster    *Cluster
+	Subspace   directory.DirectorySubspace
+	objects    map[string]*Object
+	namespaces map[string]*UniqueNamespace
+	searches   map[string]*SearchIndex
+	txOptions  *TxOptions // default options of transactions
+	// markers of committed idempotent writes
+	idempotency       subspace.Subspace
+	idempotencyWindow time.Duration
+	mux               sync.Mutex
 }
 
 // init require name and cluster properties to be set
@@ -28,7 +40,10 @@ func (d *Directory) init() {
 		panic(err)
 	}
 	d.Subspace = subspace
+	d.idempotency = subspace.Sub("idempotency")
 	d.objects = map[string]*Object{}
+	d.namespaces = map[string]*UniqueNamespace{}
+	d.searches = map[string]*SearchIndex{}
 
 	// randomising
 	// To Generate seed number we will use unix nano timestamp, plus hash from system amc adress
@@ -56,6 +71,9 @@ func (d *Directory) init() {
 
 // Object declares new object for document layer
 func (d *Directory) Object(name string, schemeObj interface{}) *ObjectBuilder {
+	if name == sharedDir {
+		panic("Stored error, object name «" + sharedDir + "» is reserved")
+	}
 	object := &Object{
 		name:      name,
 		db:        &d.Cluster.db,
@@ -91,6 +109,67 @@ func (d *Directory) Object(name string, schemeObj interface{}) *ObjectBuilder {
 	return &ob*/
 }
 
+// UniqueNamespace return namespace for unique values shared between several objects,
+// use ObjectBuilder.UniqueIn to attach object fields to it
+func (d *Directory) UniqueNamespace(name string) *UniqueNamespace {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	namespace, ok := d.namespaces[name]
+	if ok {
+		return namespace
+	}
+	dir, err := d.Subspace.CreateOrOpen(d.Cluster.db, []string{sharedDir, "unique", name}, nil)
+	if err != nil {
+		panic(err)
+	}
+	namespace = &UniqueNamespace{
+		name: name,
+		dir:  dir,
+	}
+	d.namespaces[name] = namespace
+	return namespace
+}
+
+// SearchIndex return full text search index shared between several objects,
+// use ObjectBuilder.SearchIn to attach object fields to it
+func (d *Directory) SearchIndex(name string, options ...IndexOption) *SearchIndex {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	search, ok := d.searches[name]
+	if ok {
+		return search
+	}
+	index := &Index{
+		Name:   name,
+		search: true,
+		object: &Object{name: name, db: &d.Cluster.db, directory: d}, // used only to create promises
+	}
+	index.Options(options...)
+	var err error
+	index.dir, err = d.Subspace.CreateOrOpen(d.Cluster.db, []string{sharedDir, "search", name}, nil)
+	if err != nil {
+		panic(err)
+	}
+	index.statsDir, err = d.Subspace.CreateOrOpen(d.Cluster.db, []string{sharedDir, "search", name, "stats"}, nil)
+	if err != nil {
+		panic(err)
+	}
+	if index.fuzzy {
+		index.trigramDir, err = d.Subspace.CreateOrOpen(d.Cluster.db, []string{sharedDir, "search", name, "trigram"}, nil)
+		if err != nil {
+			panic(err)
+		}
+	}
+	search = &SearchIndex{
+		name:    name,
+		index:   index,
+		objects: map[string]*Index{},
+	}
+	index.global = search
+	d.searches[name] = search
+	return search
+}
+
 // Clear removes all content inside directory
 func (d *Directory) Clear() error {
 	_, err := d.Cluster.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
@@ -125,31 +204,69 @@ func (d *Directory) Multi() *MultiChain {
 	return &db
 }*/
 
+// SetTxOptions sets default options of transactions performed by promises and transactions of the
+// directory, should be called at the init section
+func (d *Directory) SetTxOptions(options TxOptions) {
+	d.txOptions = &options
+}
+
 // Read will run callback in read transaction
 func (d *Directory) Read(callback func(*Transaction)) *Transaction {
+	return d.read(nil, d.txOptions, callback)
+}
+
+// ReadCtx will run callback in read transaction bound to the context, deadline of the context
+// becomes timeout of the transaction and ctx.Err() is returned once context is done
+func (d *Directory) ReadCtx(ctx context.Context, callback func(*Transaction)) *Transaction {
+	return d.read(ctx, d.txOptions, callback)
+}
+
+// ReadWith will run callback in read transaction performed with the options
+func (d *Directory) ReadWith(options TxOptions, callback func(*Transaction)) *Transaction {
+	return d.read(nil, &options, callback)
+}
+
+func (d *Directory) read(ctx context.Context, options *TxOptions, callback func(*Transaction)) *Transaction {
 	db := &d.Cluster.db
-	t := Transaction{db: db}
-	_, err := db.ReadTransact(func(tr fdb.ReadTransaction) (interface{}, error) {
+	t := Transaction{db: db, ctx: ctx, options: options}
+	_, err := dbReadTransact(ctx, options, db, func(tr fdb.ReadTransaction) (interface{}, error) {
 		t.initRead(tr)
 		callback(&t)
 		return nil, t.Err()
 	})
 	t.err = err
+	t.finished(err)
 	return &t
 }
 
 // Write will run callback in write transaction
 func (d *Directory) Write(callback func(*Transaction)) *Transaction {
+	return d.write(nil, d.txOptions, callback)
+}
+
+// WriteCtx will run callback in write transaction bound to the context, deadline of the context
+// becomes timeout of the transaction and ctx.Err() is returned once context is done
+func (d *Directory) WriteCtx(ctx context.Context, callback func(*Transaction)) *Transaction {
+	return d.write(ctx, d.txOptions, callback)
+}
+
+// WriteWith will run callback in write transaction performed with the options
+func (d *Directory) WriteWith(options TxOptions, callback func(*Transaction)) *Transaction {
+	return d.write(nil, &options, callback)
+}
+
+func (d *Directory) write(ctx context.Context, options *TxOptions, callback func(*Transaction)) *Transaction {
 	db := &d.Cluster.db
-	t := Transaction{db: db}
-	_, err := db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+	t := Transaction{db: db, ctx: ctx, options: options}
+	_, err := dbTransact(ctx, options, db, func(tr fdb.Transaction) (interface{}, error) {
 		t.initWrite(tr)
 		callback(&t)
 		return nil, t.Err()
 	})
-	if t.err == nil {
+	if t.err == nil || ((ctx != nil || options != nil) && err != nil) {
 		t.err = err
 	}
+	t.finished(t.err)
 	return &t
 }
 
@@ -157,8 +274,9 @@ func (d *Directory) Write(callback func(*Transaction)) *Transaction {
 func (d *Directory) Parallel(tasks ...PromiseAny) *Transaction {
 	db := &d.Cluster.db
 	t := Transaction{
-		tasks: []transactionTask{},
-		db:    db,
+		tasks:   []transactionTask{},
+		db:      db,
+		options: d.txOptions,
 	}
 	for _, task := range tasks {
 		t.tasks = append(t.tasks, transactionTask{
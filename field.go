@@ -26,6 +26,8 @@ type Field struct {
 	Value         reflect.Value
 	mutable       bool
 	primary       bool
+	descending    bool      // index keys of this field are stored in descending order
+	collation     Collation // how string values are compared inside indexes
 	AutoIncrement bool
 	GenID         GenIDType // type of ID autogeneration, IDDate, IDRandom
 	packed        *packed.Packed
@@ -42,6 +44,8 @@ type Tag struct {
 	Primary       bool
 	mutable       bool
 	unique        bool
+	descending    bool
+	collation     Collation
 	AutoIncrement bool
 	UnStored      bool // means this field doesn't stored inside main object data
 
@@ -75,7 +79,14 @@ func (f *Field) ParseTag() *Tag {
 				tag.unique = true
 			case "autoincrement":
 				tag.AutoIncrement = true
+			case "desc":
+				tag.descending = true
 			default:
+				collation, ok := parseCollation(part)
+				if ok {
+					tag.collation = collation
+					continue
+				}
 				panic("tag «" + tag.Name + "» has unsupported ‘" + part + "’ option")
 			}
 		}
@@ -163,6 +174,46 @@ func (f *Field) tupleElement(val interface{}) tuple.TupleElement {
 	return val
 }
 
+// indexElement return tuple element of the value as it should be stored inside index key,
+// applying collation and descending order of the field
+func (f *Field) indexElement(val interface{}) tuple.TupleElement {
+	element := f.tupleElement(val)
+	if f.collation != CollationBinary {
+		str, ok := element.(string)
+		if ok {
+			element = f.collation.collate(str)
+		}
+	}
+	if f.descending {
+		return descendingEncode(element)
+	}
+	return element
+}
+
+// indexValue return original tuple element from index key element
+func (f *Field) indexValue(element tuple.TupleElement) tuple.TupleElement {
+	if !f.descending {
+		return element
+	}
+	data, ok := element.([]byte)
+	if !ok {
+		return element
+	}
+	decoded, err := descendingDecode(data)
+	if err != nil {
+		return element
+	}
+	return decoded
+}
+
+// SetCollation sets collation of string field used inside indexes
+func (f *Field) SetCollation(collation Collation) {
+	if f.Kind != reflect.String {
+		f.panic("should be string to set collation")
+	}
+	f.collation = collation
+}
+
 func (f *Field) setTupleValue(value reflect.Value, interfaceValue interface{}) {
 	objField := value.Field(f.Num)
 	switch objField.Kind() {
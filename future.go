@@ -0,0 +1,325 @@
+package stored
+
+import (
+	"errors"
+)
+
+// ErrNotResolved is returned when value of the future is requested, but its promise was not finished,
+// for example promises left once Any is resolved
+var ErrNotResolved = errors.New("Future is not resolved")
+
+// ErrNoPromises is returned by Any called without promises
+var ErrNoPromises = errors.New("No promises passed")
+
+// ErrAnyWrite is returned by Any called with write promises, losing promises would be left half performed
+var ErrAnyWrite = errors.New("Any accepts only read promises")
+
+// Future is typed result of the promise, futures could be composed using Then, Map, All and Any,
+// so independent reads are performed concurrently inside one transaction
+type Future[T any] struct {
+	promise *Promise
+	value   func() (T, error) // converts result of the finished promise
+}
+
+// FutureResult is value of the future sent by Async
+type FutureResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// FutureOf return future of the promise filling the object, like Get, GetBy or GetFields
+func FutureOf[T any](promise PromiseAny, objectPtr *T) *Future[T] {
+	return &Future[T]{
+		promise: promise.self(),
+		value: func() (T, error) {
+			return *objectPtr, nil
+		},
+	}
+}
+
+// FutureValue return future of the promise fetching the value
+func FutureValue[T any](promise *PromiseValue) *Future[T] {
+	return &Future[T]{
+		promise: &promise.Promise,
+		value: func() (T, error) {
+			var res T
+			value, ok := promise.resp.(*Value)
+			if !ok {
+				return res, errors.New("promise value is not Value")
+			}
+			err := value.Scan(&res)
+			return res, err
+		},
+	}
+}
+
+// FutureSlice return future of the promise fetching the list of objects
+func FutureSlice[T any](promise *PromiseSlice) *Future[[]T] {
+	return &Future[[]T]{
+		promise: &promise.Promise,
+		value: func() ([]T, error) {
+			res := []T{}
+			slice, ok := promise.resp.(*Slice)
+			if !ok {
+				return res, errors.New("promise value is not Slice")
+			}
+			err := slice.ScanAll(&res)
+			return res, err
+		},
+	}
+}
+
+// FutureInt64 return future of the promise fetching int64, like counters
+func FutureInt64(promise *Promise) *Future[int64] {
+	return &Future[int64]{promise: promise, value: futureResp[int64](promise)}
+}
+
+// FutureBool return future of the promise fetching bool, like Relation.Check
+func FutureBool(promise *Promise) *Future[bool] {
+	return &Future[bool]{promise: promise, value: futureResp[bool](promise)}
+}
+
+// futureResp return function reading response of the promise
+func futureResp[T any](promise *Promise) func() (T, error) {
+	return func() (T, error) {
+		res, ok := promise.resp.(T)
+		if !ok {
+			return res, errors.New("promise value has different type")
+		}
+		return res, nil
+	}
+}
+
+// self return promise of the future, so future could be used as any other promise
+func (f *Future[T]) self() *Promise {
+	return f.promise
+}
+
+// Promise return promise of the future, could be used to Check or Try it within transaction
+func (f *Future[T]) Promise() *Promise {
+	return f.promise
+}
+
+// Do will attach future to transaction, so it will be performed within passed transaction
+func (f *Future[T]) Do(t *Transaction) *Future[T] {
+	f.promise.Do(t)
+	return f
+}
+
+// Wait will perform the promise if it was not performed yet and return the value
+func (f *Future[T]) Wait() (T, error) {
+	var empty T
+	// promises left unfinished by Any are bound to the transaction already, but not attached using Do
+	standalone := f.promise.readTr == nil || f.promise.transaction != nil
+	if !f.promise.confirmed && standalone {
+		_, err := f.promise.transact()
+		if err != nil {
+			return empty, err
+		}
+	}
+	if f.promise.err != nil {
+		return empty, f.promise.err
+	}
+	if !f.promise.confirmed {
+		return empty, ErrNotResolved
+	}
+	return f.value()
+}
+
+// Async will perform the future in background, result is sent to the channel
+func (f *Future[T]) Async() <-chan FutureResult[T] {
+	results := make(chan FutureResult[T], 1)
+	go func() {
+		value, err := f.Wait()
+		results <- FutureResult[T]{Value: value, Err: err}
+		close(results)
+	}()
+	return results
+}
+
+// Then will perform promise returned by fn right after the future is resolved, in the same
+// transaction. Returned future is resolved with the same value once the promise is finished,
+// promise could write so Then is always performed in write transaction
+func (f *Future[T]) Then(fn func(value T) PromiseAny) *Future[T] {
+	parent := f.promise
+	p := &Promise{db: parent.db, dir: parent.dir}
+	p.do(func() Chain {
+		return p.follow(parent, func() Chain {
+			value, err := f.value()
+			if err != nil {
+				return p.fail(err)
+			}
+			return p.follow(fn(value).self(), p.ok)
+		})
+	})
+	return &Future[T]{promise: p, value: f.value}
+}
+
+// Map return future resolved with value of the future converted by fn
+func Map[T any, R any](f *Future[T], fn func(value T) (R, error)) *Future[R] {
+	parent := f.promise
+	p := &Promise{db: parent.db, dir: parent.dir, readOnly: parent.readOnly}
+	var res R
+	p.do(func() Chain {
+		return p.follow(parent, func() Chain {
+			value, err := f.value()
+			if err != nil {
+				return p.fail(err)
+			}
+			res, err = fn(value)
+			if err != nil {
+				return p.fail(err)
+			}
+			return p.ok()
+		})
+	})
+	return &Future[R]{promise: p, value: func() (R, error) {
+		return res, nil
+	}}
+}
+
+// All return promise performing all the promises concurrently inside one transaction, promise fails
+// once any of them failed. Values are taken from futures of the promises, All of no promises is
+// resolved without transaction
+func All(promises ...PromiseAny) *Promise {
+	p, children := promiseParallel(promises)
+	p.do(func() Chain {
+		return p.parallel(children, func(k int) bool {
+			if children[k].err != nil {
+				p.fail(children[k].err)
+				return false
+			}
+			return true
+		})
+	})
+	return p
+}
+
+// Any return future performing all the promises concurrently inside one transaction, future is
+// resolved with index of the promise finished first without error. Future fails if all the
+// promises failed, other promises are not finished so their futures return ErrNotResolved. Any of
+// no promises fails with ErrNoPromises, only read promises are accepted since others are left
+// unfinished, so write promises fail with ErrAnyWrite
+func Any(promises ...PromiseAny) *Future[int] {
+	p, children := promiseParallel(promises)
+	first := -1
+	p.do(func() Chain {
+		first = -1
+		if len(children) == 0 {
+			return p.fail(ErrNoPromises)
+		}
+		if !p.readOnly {
+			return p.fail(ErrAnyWrite)
+		}
+		failed := 0
+		return p.parallel(children, func(k int) bool {
+			if children[k].err == nil {
+				first = k
+				return false
+			}
+			failed++
+			if failed == len(children) {
+				p.fail(children[k].err)
+			}
+			return true
+		})
+	})
+	return &Future[int]{promise: p, value: func() (int, error) {
+		return first, nil
+	}}
+}
+
+// promiseParallel return promise for performing the promises together, transaction is read only
+// if all of them are read only
+func promiseParallel(promises []PromiseAny) (*Promise, []*Promise) {
+	p := &Promise{readOnly: true}
+	children := make([]*Promise, len(promises))
+	for k, promise := range promises {
+		children[k] = promise.self()
+		if !children[k].readOnly {
+			p.readOnly = false
+		}
+		if p.db == nil {
+			p.db = children[k].db
+			p.dir = children[k].dir
+		}
+	}
+	return p, children
+}
+
+// follow will perform chain of the child promise inside transaction of the promise step by step,
+// so it could be performed together with other promises, next is called once child is finished
+func (p *Promise) follow(child *Promise, next func() Chain) Chain {
+	child.tr = p.tr
+	child.readTr = p.readTr
+	child.clear()
+	child.confirmed = false
+	return p.followChain(child, child.getChain(), next)
+}
+
+func (p *Promise) followChain(child *Promise, chain Chain, next func() Chain) Chain {
+	if chain == nil {
+		if child.err != nil {
+			return p.fail(child.err)
+		}
+		if child.after != nil { // errors of after promise are ignored as in transactions
+			chain = p.afterChain(child)
+			return p.followChain(child, chain, next)
+		}
+		child.confirmed = true
+		return next()
+	}
+	return func() Chain {
+		return p.followChain(child, chain(), next)
+	}
+}
+
+// afterChain return chain of the promise which should be performed after the child
+func (p *Promise) afterChain(child *Promise) Chain {
+	after := child.after().self()
+	child.after = nil
+	after.tr = p.tr
+	after.readTr = p.readTr
+	return after.getChain()
+}
+
+// parallel will perform chains of the children step by step together, so reads of all the children
+// are sent before waiting for any of them. done is called once child is finished, parallel is
+// stopped if done returns false
+func (p *Promise) parallel(children []*Promise, done func(k int) bool) Chain {
+	chains := make([]Chain, len(children))
+	for k, child := range children {
+		child.tr = p.tr
+		child.readTr = p.readTr
+		child.clear()
+		child.confirmed = false
+		chains[k] = child.getChain()
+	}
+	var step Chain
+	step = func() Chain {
+		running := false
+		for k, chain := range chains {
+			if chain == nil {
+				continue
+			}
+			chains[k] = chain()
+			child := children[k]
+			if chains[k] == nil && child.err == nil && child.after != nil {
+				chains[k] = p.afterChain(child)
+			}
+			if chains[k] != nil {
+				running = true
+				continue
+			}
+			child.confirmed = true
+			if !done(k) {
+				return nil
+			}
+		}
+		if running {
+			return step
+		}
+		return nil
+	}
+	return step()
+}
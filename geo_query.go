@@ -0,0 +1,546 @@
+package stored
+
+import (
+	"math"
+	"sort"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+	"github.com/mmcloughlin/geohash"
+)
+
+const (
+	geoScanLimit       = 10000                   // number of keys read at once for each geohash cell
+	geoCoverCells      = 64                      // maximum number of cells read by Within query
+	geoNearestRounds   = 6                       // maximum number of rings read by Nearest query
+	geoNearestKeys     = 10000                   // maximum number of index keys read by Nearest query
+	geoMetersPerDegree = 6378100 * math.Pi / 180 // same earth radius Distance use
+)
+
+// GeoPoint is the point with coordinates
+type GeoPoint struct {
+	Lat  float64
+	Long float64
+}
+
+// GeoBox is the rectangle area, like map viewport. Box crosses the antimeridian if MinLong is
+// greater than MaxLong or longitudes are outside of [-180, 180]
+type GeoBox struct {
+	MinLat  float64
+	MinLong float64
+	MaxLat  float64
+	MaxLong float64
+}
+
+// Contains checks whether the point is inside the box
+func (b GeoBox) Contains(lat, long float64) bool {
+	for _, part := range b.split() {
+		if lat >= part.MinLat && lat <= part.MaxLat && long >= part.MinLong && long <= part.MaxLong {
+			return true
+		}
+	}
+	return false
+}
+
+// split return parts of the box on both sides of the antimeridian
+func (b GeoBox) split() []GeoBox {
+	if b.MaxLong > 180 {
+		b.MaxLong -= 360
+	}
+	if b.MinLong < -180 {
+		b.MinLong += 360
+	}
+	if b.MinLong <= b.MaxLong {
+		return []GeoBox{b}
+	}
+	east, west := b, b
+	east.MaxLong = 180
+	west.MinLong = -180
+	return []GeoBox{east, west}
+}
+
+// center return center of the box
+func (b GeoBox) center() (float64, float64) {
+	parts := b.split()
+	if len(parts) == 1 {
+		return (parts[0].MinLat + parts[0].MaxLat) / 2, (parts[0].MinLong + parts[0].MaxLong) / 2
+	}
+	return (b.MinLat + b.MaxLat) / 2, geoWrap((parts[0].MinLong + parts[1].MaxLong + 360) / 2)
+}
+
+// intersects checks whether the geohash cell intersects the box
+func (b GeoBox) intersects(cell geohash.Box) bool {
+	for _, part := range b.split() {
+		if cell.MaxLat >= part.MinLat && cell.MinLat <= part.MaxLat && cell.MaxLng >= part.MinLong && cell.MinLng <= part.MaxLong {
+			return true
+		}
+	}
+	return false
+}
+
+// geoWrap return longitude moved inside [-180, 180)
+func geoWrap(long float64) float64 {
+	return math.Mod(math.Mod(long+180, 360)+360, 360) - 180
+}
+
+// geoPolygonBox return bounding box of the polygon
+func geoPolygonBox(polygon []GeoPoint) GeoBox {
+	box := GeoBox{MinLat: polygon[0].Lat, MinLong: polygon[0].Long, MaxLat: polygon[0].Lat, MaxLong: polygon[0].Long}
+	for _, point := range polygon[1:] {
+		box.MinLat = math.Min(box.MinLat, point.Lat)
+		box.MinLong = math.Min(box.MinLong, point.Long)
+		box.MaxLat = math.Max(box.MaxLat, point.Lat)
+		box.MaxLong = math.Max(box.MaxLong, point.Long)
+	}
+	return box
+}
+
+// geoPolygonContains checks whether the point is inside the polygon using ray casting
+func geoPolygonContains(polygon []GeoPoint, lat, long float64) bool {
+	inside := false
+	for k, j := 0, len(polygon)-1; k < len(polygon); j, k = k, k+1 {
+		a, b := polygon[k], polygon[j]
+		if (a.Lat > lat) != (b.Lat > lat) && long < (b.Long-a.Long)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Long {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// GeoHit is the object found by geo query with distance to it
+type GeoHit struct {
+	Distance float64     // distance in meters from the point of the query
+	Primary  tuple.Tuple // primary key of the object found
+	Value    *Value
+}
+
+// GeoQuery is geo index query builder
+type GeoQuery struct {
+	index     *Index
+	lat       float64
+	long      float64
+	radius    float64 // meters, zero means all objects of neighbouring cells
+	limit     int
+	partition tuple.Tuple // values of partition fields preceding the geohash
+	nearest   int         // number of nearest objects to find, rings are expanded until found
+	box       *GeoBox     // area of Within query
+	polygon   []GeoPoint  // area of WithinPolygon query
+}
+
+// Radius will return only objects within the distance (in meters) from the point, nearest first
+func (gq *GeoQuery) Radius(meters float64) *GeoQuery {
+	gq.radius = meters
+	return gq
+}
+
+// Limit sets maximum number of results, Nearest query always returns k objects passed to it
+func (gq *GeoQuery) Limit(limit int) *GeoQuery {
+	gq.limit = limit
+	return gq
+}
+
+// Where will restrict query to objects of the partition, values should follow partition fields of the index
+func (gq *GeoQuery) Where(partition ...interface{}) *GeoQuery {
+	gq.partition = gq.index.encodePartition(partition)
+	return gq
+}
+
+// Hits will return found objects with distances, nearest first
+func (gq *GeoQuery) Hits() ([]GeoHit, error) {
+	return gq.PromiseHits().Hits()
+}
+
+// PromiseHits will return promise of found objects with distances
+func (gq *GeoQuery) PromiseHits() *PromiseGeoHits {
+	p := gq.index.object.promiseGeoHits()
+	p.doRead(func() Chain {
+		return gq.run(&p.Promise, func(hits []GeoHit) Chain {
+			return p.done(hits)
+		})
+	})
+	return p
+}
+
+// Promise will return promise of found objects, nearest first
+func (gq *GeoQuery) Promise() *PromiseSlice {
+	p := gq.index.object.promiseSlice()
+	p.doRead(func() Chain {
+		return gq.run(&p.Promise, p.doneGeoHits)
+	})
+	return p
+}
+
+// ScanAll will fill slice with found objects, nearest first
+func (gq *GeoQuery) ScanAll(slicePointer interface{}) error {
+	return gq.Promise().ScanAll(slicePointer)
+}
+
+// Slice will return slice of found objects, nearest first
+func (gq *GeoQuery) Slice() *Slice {
+	return gq.Promise().Slice()
+}
+
+// Do will return promise of the query attached to the transaction
+func (gq *GeoQuery) Do(tr *Transaction) *PromiseSlice {
+	return gq.Promise().Do(tr)
+}
+
+// TryAll performs query within the transaction, see PromiseSlice.TryAll
+func (gq *GeoQuery) TryAll(tr *Transaction, slicePointer interface{}) {
+	gq.Promise().TryAll(tr, slicePointer)
+}
+
+// CheckAll performs query within the transaction, see PromiseSlice.CheckAll
+func (gq *GeoQuery) CheckAll(tr *Transaction, slicePointer interface{}) {
+	gq.Promise().CheckAll(tr, slicePointer)
+}
+
+// run will read cells covering the query and filter objects by the radius or the area
+func (gq *GeoQuery) run(p *Promise, finish func(hits []GeoHit) Chain) Chain {
+	i := gq.index
+	precision := i.Geo
+	if precision > 12 {
+		precision = 12 // longest geohash index stores
+	}
+	if len(gq.partition) != len(i.partition) {
+		return p.fail(ErrPartitionRequired)
+	}
+	sub := i.dir.Sub(gq.partition...)
+	if gq.nearest > 0 {
+		return gq.runNearest(p, sub, precision, finish)
+	}
+	if gq.box != nil {
+		return i.geoScan(p, sub, geoCover(*gq.box, precision), func(hash string) bool {
+			return gq.box.intersects(geohash.BoundingBox(hash))
+		}, func(lat, long float64) (float64, bool) {
+			if !gq.box.Contains(lat, long) || (gq.polygon != nil && !geoPolygonContains(gq.polygon, lat, long)) {
+				return 0, false
+			}
+			return Distance(gq.lat, gq.long, lat, long), true
+		}, gq.limit, finish)
+	}
+	if gq.radius > 0 {
+		precision = geoPrecision(gq.lat, gq.long, gq.radius, precision)
+	}
+	cells := geoNeighbors(gq.lat, gq.long, precision)
+	return i.geoScan(p, sub, cells, func(hash string) bool {
+		min, _ := geoCellDistance(gq.lat, gq.long, hash)
+		return gq.radius <= 0 || min <= gq.radius
+	}, func(lat, long float64) (float64, bool) {
+		distance := Distance(gq.lat, gq.long, lat, long)
+		return distance, gq.radius <= 0 || distance <= gq.radius
+	}, gq.limit, finish)
+}
+
+// nearestCandidate is the object found by the index key, its position is known up to the geohash cell
+type nearestCandidate struct {
+	primary tuple.Tuple
+	min     float64 // distance to the nearest point of the cell
+	max     float64 // distance to the farthest point of the cell
+}
+
+// runNearest will read index keys of the cell of the point and its neighbours, precision is decreased
+// (ring is expanded) until k objects are found closer than the nearest border of the cells read.
+// Number of rounds and keys read are limited, so sparse data returns objects found so far. Objects
+// are fetched only for candidates which could be closer than k-th one
+func (gq *GeoQuery) runNearest(p *Promise, sub subspace.Subspace, precision int, finish func(hits []GeoHit) Chain) Chain {
+	candidates := map[string]*nearestCandidate{}
+	budget := geoNearestKeys
+	var round func(precision, rounds int) Chain
+	round = func(precision, rounds int) Chain {
+		cells := []string{}
+		seen := map[string]bool{}
+		for _, cell := range geoNeighbors(gq.lat, gq.long, precision) {
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+		limit := budget / len(cells)
+		if limit < 1 {
+			limit = 1
+		}
+		rangeResults := make([]fdb.RangeResult, len(cells))
+		for k, cell := range cells {
+			rangeResults[k] = p.readTr.GetRange(searchPrefixRange(sub, cell), fdb.RangeOptions{Limit: limit})
+		}
+		return func() Chain {
+			truncated := false
+			for _, res := range rangeResults {
+				rows, err := res.GetSliceWithError()
+				if err != nil {
+					return p.fail(err)
+				}
+				budget -= len(rows)
+				if len(rows) == limit {
+					truncated = true
+				}
+				for _, row := range rows {
+					key, err := sub.Unpack(row.Key)
+					if err != nil {
+						return p.fail(err)
+					}
+					if len(key) < 2 {
+						continue
+					}
+					primaryKey := string(key[1:].Pack())
+					if candidates[primaryKey] != nil {
+						continue
+					}
+					hash, _ := key[0].(string)
+					min, max := geoCellDistance(gq.lat, gq.long, hash)
+					candidates[primaryKey] = &nearestCandidate{primary: key[1:], min: min, max: max}
+				}
+			}
+			height, width := geoCellSize(gq.lat, gq.long, precision)
+			confirmed := math.Min(height, width)
+			within := 0
+			for _, candidate := range candidates {
+				if candidate.max <= confirmed {
+					within++
+				}
+			}
+			if within < gq.nearest && precision > 1 && rounds+1 < geoNearestRounds && budget > 0 && !truncated {
+				step := 1
+				if len(candidates) == 0 && precision > 2 { // nothing around, expand faster
+					step = 2
+				}
+				return round(precision-step, rounds+1)
+			}
+			return gq.fetchNearest(p, candidates, finish)
+		}
+	}
+	return round(precision, 0)
+}
+
+// fetchNearest will fetch candidates which could be closer than k-th candidate and return k nearest
+func (gq *GeoQuery) fetchNearest(p *Promise, candidates map[string]*nearestCandidate, finish func(hits []GeoHit) Chain) Chain {
+	list := []*nearestCandidate{}
+	for _, candidate := range candidates {
+		list = append(list, candidate)
+	}
+	if len(list) == 0 {
+		return finish([]GeoHit{})
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].max < list[b].max
+	})
+	k := gq.nearest
+	if k > len(list) {
+		k = len(list)
+	}
+	bound := list[k-1].max
+	primaries := []tuple.Tuple{}
+	for _, candidate := range list {
+		if candidate.min <= bound {
+			primaries = append(primaries, candidate.primary)
+		}
+	}
+	return gq.index.geoFetch(p, primaries, func(lat, long float64) (float64, bool) {
+		return Distance(gq.lat, gq.long, lat, long), true
+	}, gq.nearest, finish)
+}
+
+// geoCellDistance return distances from the point to the nearest and the farthest points of the cell
+func geoCellDistance(lat, long float64, hash string) (float64, float64) {
+	cell := geohash.BoundingBox(hash)
+	_, centerLong := cell.Center()
+	if long-centerLong > 180 { // cell is on the other side of the antimeridian
+		long -= 360
+	} else if centerLong-long > 180 {
+		long += 360
+	}
+	nearLat := math.Max(cell.MinLat, math.Min(lat, cell.MaxLat))
+	nearLong := math.Max(cell.MinLng, math.Min(long, cell.MaxLng))
+	min := Distance(lat, long, nearLat, nearLong)
+	max := 0.0
+	for _, cornerLat := range []float64{cell.MinLat, cell.MaxLat} {
+		for _, cornerLong := range []float64{cell.MinLng, cell.MaxLng} {
+			max = math.Max(max, Distance(lat, long, cornerLat, cornerLong))
+		}
+	}
+	return min, max
+}
+
+// geoNeighbors return the cell of the point and 8 neighbouring cells, longitude is wrapped at
+// the antimeridian and cells beyond the poles are skipped
+func geoNeighbors(lat, long float64, precision int) []string {
+	hash := geohash.EncodeWithPrecision(lat, geoWrap(long), uint(precision))
+	cell := geohash.BoundingBox(hash)
+	centerLat, centerLong := cell.Center()
+	height, width := cell.MaxLat-cell.MinLat, cell.MaxLng-cell.MinLng
+	cells := []string{hash}
+	for dLat := -1; dLat <= 1; dLat++ {
+		cellLat := centerLat + float64(dLat)*height
+		if cellLat > 90 || cellLat < -90 {
+			continue
+		}
+		for dLong := -1; dLong <= 1; dLong++ {
+			if dLat == 0 && dLong == 0 {
+				continue
+			}
+			cellLong := geoWrap(centerLong + float64(dLong)*width)
+			cells = append(cells, geohash.EncodeWithPrecision(cellLat, cellLong, uint(precision)))
+		}
+	}
+	return cells
+}
+
+// geoCover return cells of largest precision (up to the max) covering the box with limited number of
+// cells, box crossing the antimeridian is covered by both parts
+func geoCover(box GeoBox, max int) []string {
+	parts := box.split()
+	cells := []string{}
+	for _, part := range parts {
+		cells = append(cells, geoCoverPart(part, max, geoCoverCells/len(parts))...)
+	}
+	return cells
+}
+
+// geoCoverPart return cells covering the box which does not cross the antimeridian
+func geoCoverPart(box GeoBox, max int, limit int) []string {
+	for precision := max; ; precision-- {
+		corner := geohash.BoundingBox(geohash.EncodeWithPrecision(box.MinLat, box.MinLong, uint(precision)))
+		height, width := corner.MaxLat-corner.MinLat, corner.MaxLng-corner.MinLng
+		rows := int((box.MaxLat-corner.MinLat)/height) + 1
+		cols := int((box.MaxLong-corner.MinLng)/width) + 1
+		if rows*cols > limit && precision > 1 {
+			continue
+		}
+		cells := []string{}
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				lat := corner.MinLat + height*(float64(r)+0.5)
+				long := corner.MinLng + width*(float64(c)+0.5)
+				if lat > 90 || long > 180 { // box reaches the pole or the antimeridian
+					continue
+				}
+				cells = append(cells, geohash.EncodeWithPrecision(lat, long, uint(precision)))
+			}
+		}
+		return cells
+	}
+}
+
+// geoPrecision return precision of geohash which cell and its neighbours cover the circle
+func geoPrecision(lat, long, radius float64, max int) int {
+	for precision := max; precision > 1; precision-- {
+		height, width := geoCellSize(lat, long, precision)
+		if height >= radius && width >= radius {
+			return precision
+		}
+	}
+	return 1
+}
+
+// geoCellSize return height and width in meters of the geohash cell containing the point
+func geoCellSize(lat, long float64, precision int) (float64, float64) {
+	box := geohash.BoundingBox(geohash.EncodeWithPrecision(lat, long, uint(precision)))
+	height := (box.MaxLat - box.MinLat) * geoMetersPerDegree
+	width := (box.MaxLng - box.MinLng) * geoMetersPerDegree * math.Cos(lat*math.Pi/180)
+	return height, width
+}
+
+// geoScan will fetch objects stored inside the cells (geohash prefixes) of the partition subspace,
+// objects are matched by coordinates and returned ordered by distance. Index keys of the cells which
+// could not match (cellMatch checks the cell stored in the key) are skipped before objects are fetched,
+// cells holding more than geoScanLimit keys are read page by page
+func (i *Index) geoScan(p *Promise, sub subspace.Subspace, cells []string, cellMatch func(hash string) bool,
+	match func(lat, long float64) (float64, bool), limit int, finish func(hits []GeoHit) Chain) Chain {
+	ranges := []fdb.KeyRange{}
+	seen := map[string]bool{}
+	for _, cell := range cells {
+		if seen[cell] {
+			continue
+		}
+		seen[cell] = true
+		ranges = append(ranges, searchPrefixRange(sub, cell))
+	}
+	primaries := []tuple.Tuple{}
+	found := map[string]bool{}
+	matched := map[string]bool{}
+	var read func(ranges []fdb.KeyRange) Chain
+	read = func(ranges []fdb.KeyRange) Chain {
+		rangeResults := make([]fdb.RangeResult, len(ranges))
+		for k, keyRange := range ranges {
+			rangeResults[k] = p.readTr.GetRange(keyRange, fdb.RangeOptions{Limit: geoScanLimit})
+		}
+		return func() Chain {
+			next := []fdb.KeyRange{}
+			for k, res := range rangeResults {
+				rows, err := res.GetSliceWithError()
+				if err != nil {
+					return p.fail(err)
+				}
+				if len(rows) == geoScanLimit {
+					begin := fdb.Key(append(append([]byte{}, rows[len(rows)-1].Key...), 0x00))
+					next = append(next, fdb.KeyRange{Begin: begin, End: ranges[k].End})
+				}
+				for _, row := range rows {
+					key, err := sub.Unpack(row.Key)
+					if err != nil {
+						return p.fail(err)
+					}
+					if len(key) < 2 {
+						continue
+					}
+					hash, _ := key[0].(string)
+					ok, checked := matched[hash]
+					if !checked {
+						ok = cellMatch(hash)
+						matched[hash] = ok
+					}
+					if !ok {
+						continue
+					}
+					primaryTuple := key[1:]
+					primaryKey := string(primaryTuple.Pack())
+					if found[primaryKey] {
+						continue
+					}
+					found[primaryKey] = true
+					primaries = append(primaries, primaryTuple)
+				}
+			}
+			if len(next) > 0 {
+				return read(next)
+			}
+			return i.geoFetch(p, primaries, match, limit, finish)
+		}
+	}
+	return read(ranges)
+}
+
+// geoFetch will fetch objects, match them by coordinates and return ordered by distance
+func (i *Index) geoFetch(p *Promise, primaries []tuple.Tuple, match func(lat, long float64) (float64, bool), limit int,
+	finish func(hits []GeoHit) Chain) Chain {
+	need := make([]*needObject, len(primaries))
+	for k, primaryTuple := range primaries {
+		need[k] = i.object.need(p.readTr, i.object.sub(primaryTuple))
+	}
+	return func() Chain {
+		hits := []GeoHit{}
+		for k, n := range need {
+			val, err := n.fetch()
+			if err != nil {
+				continue
+			}
+			input := structAny(val.Interface())
+			lat, _ := input.Get(i.fields[0]).(float64)
+			long, _ := input.Get(i.fields[1]).(float64)
+			distance, ok := match(lat, long)
+			if !ok {
+				continue
+			}
+			hits = append(hits, GeoHit{Distance: distance, Primary: primaries[k], Value: val})
+		}
+		sort.SliceStable(hits, func(a, b int) bool {
+			return hits[a].Distance < hits[b].Distance
+		})
+		if limit > 0 && len(hits) > limit {
+			hits = hits[:limit]
+		}
+		return finish(hits)
+	}
+}
@@ -0,0 +1,205 @@
+package stored
+
+import (
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+const (
+	idempotencyDefaultWindow = 24 * time.Hour // time markers of committed writes are kept
+	idempotencyCleanupLimit  = 16             // number of expired markers removed by each write
+)
+
+// idempotent describes write which should be committed only once for the key
+type idempotent struct {
+	object  string // name of the object keys are scoped by, empty for WriteIdempotent
+	key     string
+	result  func() tuple.Tuple       // data stored within the marker, like primary of added object
+	restore func(result tuple.Tuple) // called instead of the write, if it was already committed
+}
+
+// idempotencyMarker is marker of the write committed for the key
+type idempotencyMarker struct {
+	expire int64 // unix nano time the marker expires at, zero if there is no marker
+	result tuple.Tuple
+}
+
+// committed return true if write with the key was committed and marker is not expired yet
+func (m *idempotencyMarker) committed() bool {
+	return m.expire > time.Now().UnixNano()
+}
+
+// SetIdempotencyWindow sets how long idempotency keys of committed writes are remembered,
+// repeated write with the same key after the window will be performed again
+func (d *Directory) SetIdempotencyWindow(window time.Duration) {
+	d.idempotencyWindow = window
+}
+
+// WriteIdempotent will run callback in write transaction only if no transaction with the same key
+// was committed within idempotency window. Marker of the key is written in the same transaction,
+// so retries after commit_unknown_result will not perform the writes twice
+func (d *Directory) WriteIdempotent(key string, callback func(*Transaction)) *Transaction {
+	return d.write(nil, d.txOptions, func(t *Transaction) {
+		marker, err := d.getMarker(t.tr, "", key)
+		if err != nil {
+			t.err = err
+			return
+		}
+		if marker.committed() {
+			return
+		}
+		callback(t)
+		if t.Err() == nil {
+			t.err = d.setMarker(t.tr, "", key, marker, nil)
+		}
+	})
+}
+
+// getMarker will read marker of the key written for the object, read is not snapshot so concurrent writes
+// with the same key will conflict
+func (d *Directory) getMarker(tr fdb.Transaction, object, key string) (*idempotencyMarker, error) {
+	marker := idempotencyMarker{}
+	bytes, err := tr.Get(d.idempotency.Pack(tuple.Tuple{"key", object, key})).Get()
+	if err != nil || bytes == nil {
+		return &marker, err
+	}
+	return &marker, marker.unpack(bytes)
+}
+
+func (m *idempotencyMarker) unpack(bytes []byte) error {
+	value, err := tuple.Unpack(bytes)
+	if err != nil {
+		return err
+	}
+	if len(value) != 2 {
+		return ErrDataCorrupt
+	}
+	expire, ok := value[0].(int64)
+	if !ok {
+		return ErrDataCorrupt
+	}
+	result, ok := value[1].(tuple.Tuple)
+	if !ok {
+		return ErrDataCorrupt
+	}
+	m.expire = expire
+	m.result = result
+	return nil
+}
+
+// setMarker will write marker of the key replacing the expired one, also removes some of expired markers
+func (d *Directory) setMarker(tr fdb.Transaction, object, key string, old *idempotencyMarker, result tuple.Tuple) error {
+	if old.expire != 0 {
+		tr.Clear(d.idempotency.Pack(tuple.Tuple{"expire", old.expire, object, key}))
+	}
+	window := d.idempotencyWindow
+	if window == 0 {
+		window = idempotencyDefaultWindow
+	}
+	if result == nil {
+		result = tuple.Tuple{}
+	}
+	now := time.Now().UnixNano()
+	expire := now + int64(window)
+	tr.Set(d.idempotency.Pack(tuple.Tuple{"key", object, key}), tuple.Tuple{expire, result}.Pack())
+	tr.Set(d.idempotency.Pack(tuple.Tuple{"expire", expire, object, key}), []byte{})
+	return d.cleanupMarkers(tr, now)
+}
+
+// cleanupMarkers removes markers expired before now, expire list is read as snapshot so cleanups
+// do not conflict with each other, marker is removed only if it was not written again
+func (d *Directory) cleanupMarkers(tr fdb.Transaction, now int64) error {
+	expireSub := d.idempotency.Sub("expire")
+	start, _ := expireSub.FDBRangeKeys()
+	r := fdb.KeyRange{Begin: start, End: expireSub.Pack(tuple.Tuple{now})}
+	rows, err := tr.Snapshot().GetRange(r, fdb.RangeOptions{Limit: idempotencyCleanupLimit}).GetSliceWithError()
+	if err != nil {
+		return err
+	}
+	keys := []fdb.Key{}
+	markers := []fdb.FutureByteSlice{}
+	for _, row := range rows {
+		expireTuple, err := expireSub.Unpack(row.Key)
+		if err != nil {
+			return err
+		}
+		if len(expireTuple) != 3 {
+			return ErrDataCorrupt
+		}
+		object, ok := expireTuple[1].(string)
+		if !ok {
+			return ErrDataCorrupt
+		}
+		key, ok := expireTuple[2].(string)
+		if !ok {
+			return ErrDataCorrupt
+		}
+		tr.Clear(row.Key)
+		markerKey := d.idempotency.Pack(tuple.Tuple{"key", object, key})
+		keys = append(keys, markerKey)
+		markers = append(markers, tr.Get(markerKey))
+	}
+	for k, future := range markers {
+		bytes, err := future.Get()
+		if err != nil {
+			return err
+		}
+		if bytes == nil {
+			continue
+		}
+		marker := idempotencyMarker{}
+		err = marker.unpack(bytes)
+		if err != nil {
+			return err
+		}
+		if marker.expire <= now {
+			tr.Clear(keys[k])
+		}
+	}
+	return nil
+}
+
+// idempotentChain will check marker of the key before the chain of the promise, chain is skipped
+// if the write was already committed, otherwise marker is written once the chain is finished
+func (p *Promise) idempotentChain(chain Chain) Chain {
+	object, key := p.idempotent.object, p.idempotent.key
+	return func() Chain {
+		marker, err := p.dir.getMarker(p.tr, object, key)
+		if err != nil {
+			return p.fail(err)
+		}
+		if marker.committed() {
+			if p.idempotent.restore != nil {
+				p.idempotent.restore(marker.result)
+			}
+			return p.ok()
+		}
+		return chainFinally(chain, func() {
+			if p.err != nil {
+				return
+			}
+			var result tuple.Tuple
+			if p.idempotent.result != nil {
+				result = p.idempotent.result()
+			}
+			err := p.dir.setMarker(p.tr, object, key, marker, result)
+			if err != nil {
+				p.err = err
+			}
+		})
+	}
+}
+
+// chainFinally will call fn once the last function of the chain is performed
+func chainFinally(chain Chain, fn func()) Chain {
+	return func() Chain {
+		next := chain()
+		if next == nil {
+			fn()
+			return nil
+		}
+		return chainFinally(next, fn)
+	}
+}
@@ -2,7 +2,6 @@ package stored
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"reflect"
 
@@ -13,17 +12,35 @@ import (
 	"github.com/mmcloughlin/geohash"
 )
 
+const clearBatch = 1000 // number of keys read by one transaction clearing data of shared index
+
 // Index represend all indexes sored has
 type Index struct {
 	Name         string
 	Unique       bool
-	Geo          int  // geo precision used to
-	search       bool // means for each word
+	Geo          int          // geo precision used to
+	search       bool         // means for each word
+	fuzzy        bool         // search index also stores trigrams to find misspelled words
+	partition    []*Field     // fields prepended to search index keys
+	global       *SearchIndex // search index shared between objects
 	dir          directory.DirectorySubspace
 	valueDir     directory.DirectorySubspace
+	countDir     directory.DirectorySubspace
+	statsDir     directory.DirectorySubspace // document lengths and totals of search index
+	trigramDir   directory.DirectorySubspace // character trigrams of fuzzy search index
+	centroidDir  directory.DirectorySubspace // centroids of vector index lists
 	object       *Object
 	optional     bool
+	counted      bool // maintain number of objects for each prefix of index key
+	versionstamp bool // keys ordered by commit version of the transaction added the object
+	vectorDims   int  // number of dimensions of vector index
+	vectorLists  int  // number of centroids of vector index
+	vectorMetric VectorMetric
+	vectorCache  *vectorCache // centroids read by the last transaction
+	namespace    *UniqueNamespace
+	covered      []*Field // fields stored alongside the index key
 	fields       []*Field
+	analyzer     Analyzer // used by search index to split text into terms
 	handle       func(interface{}) KeyTuple
 	checkHandler func(obj interface{}) bool
 }
@@ -32,6 +49,10 @@ type Index struct {
 type IndexOption struct {
 	// CheckHandler describes should index be written for specific object or not
 	CheckHandler func(obj interface{}) bool
+	// Analyzer splits text into terms for search index, AnalyzerDefault is used if not set
+	Analyzer Analyzer
+	// Fuzzy makes search index store character trigrams, so misspelled words could be found
+	Fuzzy bool
 }
 
 func (i *Index) isEmpty(input *Struct) bool {
@@ -71,12 +92,12 @@ func (i *Index) getKey(input *Struct) (key tuple.Tuple) {
 			if i.Geo < 12 {
 				hash = hash[0:i.Geo] // Cutting hash to needed precision
 			}
-			key = append(key, hash)
+			key = append(i.searchPartition(input), hash)
 		} else {
 			//key = tuple.Tuple{indexValue}
 			for _, field := range i.fields {
 				indexValue := input.Get(field)
-				key = append(key, field.tupleElement(indexValue))
+				key = append(key, field.indexElement(indexValue))
 			}
 		}
 	}
@@ -84,7 +105,7 @@ func (i *Index) getKey(input *Struct) (key tuple.Tuple) {
 }
 
 func (i *Index) needValueStore() bool {
-	if i.handle != nil {
+	if i.handle != nil || i.versionstamp || i.vectorDims != 0 {
 		return true
 	}
 	return false
@@ -106,56 +127,135 @@ func (i *Index) getOldKey(tr fdb.Transaction, primaryTuple tuple.Tuple, oldObjec
 	return i.getKey(oldObject), nil
 }
 
-// writeSearch will set new index keys and delete old ones for text search index
+// writeSearch will set new index keys and delete old ones for text search index,
+// value of each key is term frequency followed by positions of the word, document length is stored for ranking
 func (i *Index) writeSearch(tr fdb.Transaction, primaryTuple tuple.Tuple, input, oldObject *Struct) error {
+	primaryTuple = i.searchPrimary(primaryTuple)
 	newWords := searchGetInputWords(i, input)
-	toAddWords := map[string]bool{}
-	skip := false
 	if i.checkHandler != nil {
 		if !i.checkHandler(input.value.Interface()) {
 			//fmt.Println("skipping index")
-			skip = true
+			newWords = nil
 		}
 		// old value is better to delete any way
 	}
-	if !skip {
-		for _, word := range newWords {
-			toAddWords[word] = true
-		}
-		fmt.Println("index words >>", newWords)
+	partition := i.searchPartition(input)
+	toAddWords := map[string]tuple.Tuple{} // positions of each word
+	for position, word := range newWords {
+		toAddWords[word] = append(toAddWords[word], int64(position))
 	}
-	toDeleteWords := map[string]bool{}
+	wasIndexed, err := i.writeSearchLength(tr, primaryTuple, len(newWords) != 0, int64(len(newWords)))
+	if err != nil {
+		return err
+	}
+	var oldWords []string
 	if oldObject != nil {
-		oldWords := searchGetInputWords(i, oldObject)
-		for _, word := range oldWords {
-			_, ok := toAddWords[word]
-			if ok {
-				delete(toAddWords, word)
-			} else {
-				toDeleteWords[word] = true
-			}
-
+		oldWords = searchGetInputWords(i, oldObject)
+		oldPartition := i.searchPartition(oldObject)
+		if !reflect.DeepEqual(oldPartition, partition) { // object moved to other partition
+			i.clearSearch(tr, oldPartition, primaryTuple, oldWords, wasIndexed)
+			oldWords, wasIndexed = nil, false
 		}
 	}
-	for word := range toAddWords {
-		key := tuple.Tuple{word}
-		fullKey := append(key, primaryTuple...)
-		fmt.Println("write search key", fullKey, "packed", i.dir.Pack(fullKey))
-		tr.Set(i.dir.Pack(fullKey), []byte{})
+	for _, word := range oldWords {
+		if _, ok := toAddWords[word]; !ok {
+			tr.Clear(i.dir.Pack(searchKey(partition, word, primaryTuple)))
+		}
 	}
-	for word := range toDeleteWords {
-		key := tuple.Tuple{word}
-		fullKey := append(key, primaryTuple...)
-		tr.Clear(i.dir.Pack(fullKey))
+	for word, positions := range toAddWords {
+		tr.Set(i.dir.Pack(searchKey(partition, word, primaryTuple)), append(Int64(int64(len(positions))), positions.Pack()...))
 	}
+	if !wasIndexed {
+		oldWords = nil
+	}
+	if i.fuzzy {
+		i.writeTrigrams(tr, partition, primaryTuple, newWords, oldWords)
+	}
+	i.countTerms(tr, partition, newWords, oldWords)
 	return nil
 }
 
+// deleteSearch will remove all the search keys of the object
+func (i *Index) deleteSearch(tr fdb.Transaction, primaryTuple tuple.Tuple, object *Struct) error {
+	primaryTuple = i.searchPrimary(primaryTuple)
+	wasIndexed, err := i.writeSearchLength(tr, primaryTuple, false, 0)
+	if err != nil {
+		return err
+	}
+	i.clearSearch(tr, i.searchPartition(object), primaryTuple, searchGetInputWords(i, object), wasIndexed)
+	return nil
+}
+
+// clearSearch will remove words of the object from the partition, counters are updated only
+// if the object was indexed
+func (i *Index) clearSearch(tr fdb.Transaction, partition, primaryTuple tuple.Tuple, words []string, indexed bool) {
+	for _, word := range words {
+		tr.Clear(i.dir.Pack(searchKey(partition, word, primaryTuple)))
+	}
+	if !indexed {
+		words = nil
+	}
+	if i.fuzzy {
+		i.writeTrigrams(tr, partition, primaryTuple, nil, words)
+	}
+	i.countTerms(tr, partition, nil, words)
+}
+
+// writeSearchLength will store length of the document and update totals of the index,
+// returns true if the document was indexed before
+func (i *Index) writeSearchLength(tr fdb.Transaction, primaryTuple tuple.Tuple, indexed bool, length int64) (bool, error) {
+	lengthKey := i.statsDir.Sub(searchStatsLength).Pack(primaryTuple)
+	oldBytes, err := tr.Get(lengthKey).Get()
+	if err != nil {
+		return false, err
+	}
+	if len(oldBytes) != 0 {
+		tr.Add(i.statsDir.Pack(tuple.Tuple{searchStatsDocs}), countDec)
+		tr.Add(i.statsDir.Pack(tuple.Tuple{searchStatsTerms}), Int64(-ToInt64(oldBytes)))
+	}
+	if !indexed {
+		tr.Clear(lengthKey)
+		return len(oldBytes) != 0, nil
+	}
+	tr.Set(lengthKey, Int64(length))
+	tr.Add(i.statsDir.Pack(tuple.Tuple{searchStatsDocs}), countInc)
+	tr.Add(i.statsDir.Pack(tuple.Tuple{searchStatsTerms}), Int64(length))
+	return len(oldBytes) != 0, nil
+}
+
+// countTerms will update number of documents containing each term, used by suggestions
+func (i *Index) countTerms(tr fdb.Transaction, partition tuple.Tuple, newWords, oldWords []string) {
+	newSet := map[string]bool{}
+	for _, word := range newWords {
+		newSet[word] = true
+	}
+	oldSet := map[string]bool{}
+	for _, word := range oldWords {
+		oldSet[word] = true
+	}
+	for word := range newSet {
+		if !oldSet[word] {
+			tr.Add(i.statsDir.Sub(searchStatsTerm).Pack(searchKey(partition, word, nil)), countInc)
+		}
+	}
+	for word := range oldSet {
+		if !newSet[word] {
+			tr.Add(i.statsDir.Sub(searchStatsTerm).Pack(searchKey(partition, word, nil)), countDec)
+		}
+	}
+}
+
 // Write writes index related keys
 func (i *Index) Write(tr fdb.Transaction, primaryTuple tuple.Tuple, input, oldObject *Struct) error {
 	if i.search {
 		return i.writeSearch(tr, primaryTuple, input, oldObject)
 	}
+	if i.versionstamp {
+		return i.writeVersionstamp(tr, primaryTuple, input)
+	}
+	if i.vectorDims != 0 {
+		return i.writeVector(tr, primaryTuple, input)
+	}
 	key := i.getKey(input)
 	if oldObject != nil {
 		toDelete, err := i.getOldKey(tr, primaryTuple, oldObject)
@@ -164,6 +264,10 @@ func (i *Index) Write(tr fdb.Transaction, primaryTuple tuple.Tuple, input, oldOb
 		}
 		if toDelete != nil {
 			if reflect.DeepEqual(toDelete, key) {
+				if i.covered != nil && !i.Unique { // key is same, but covered fields could change
+					fullKey := append(key, primaryTuple...)
+					tr.Set(i.dir.Pack(fullKey), i.coverValue(input))
+				}
 				return nil
 			}
 			i.Delete(tr, primaryTuple, toDelete)
@@ -180,27 +284,81 @@ func (i *Index) Write(tr fdb.Transaction, primaryTuple tuple.Tuple, input, oldOb
 	if i.Unique {
 		previousPromise := tr.Get(i.dir.Pack(key))
 
-		tr.Set(i.dir.Pack(key), primaryTuple.Pack()) // will be cancelled in case of error
+		value := primaryTuple.Pack()
+		if i.namespace != nil {
+			value = i.namespace.ownerValue(i.object, primaryTuple)
+		}
+		tr.Set(i.dir.Pack(key), value) // will be cancelled in case of error
 
 		previousBytes, err := previousPromise.Get()
 		if err != nil {
 			return err
 		}
 		if len(previousBytes) != 0 {
-			if !bytes.Equal(primaryTuple.Pack(), previousBytes) {
-				return ErrAlreadyExist
+			if !bytes.Equal(value, previousBytes) {
+				if i.namespace != nil {
+					return i.namespaceViolation(key, previousBytes)
+				}
+				return i.uniqueViolation(key, previousBytes)
 			}
 		}
 	} else {
 		fullKey := append(key, primaryTuple...)
-		tr.Set(i.dir.Pack(fullKey), []byte{})
+		tr.Set(i.dir.Pack(fullKey), i.coverValue(input))
 	}
 	if i.needValueStore() {
 		tr.Set(i.valueDir.Pack(primaryTuple), key.Pack())
 	}
+	if i.counted {
+		i.count(tr, key, countInc)
+	}
 	return nil
 }
 
+// uniqueViolation return error describing the object owning value of the unique index
+func (i *Index) uniqueViolation(key tuple.Tuple, ownerBytes []byte) error {
+	ownerPrimary, err := tuple.Unpack(ownerBytes)
+	if err != nil {
+		return &CorruptionError{Key: i.dir.Pack(key), Reason: err.Error()}
+	}
+	return &UniqueViolation{
+		Object:          i.object.name,
+		Index:           i.Name,
+		Value:           key,
+		ExistingPrimary: ownerPrimary,
+	}
+}
+
+// namespaceViolation return error describing the object owning value inside the unique namespace
+func (i *Index) namespaceViolation(key tuple.Tuple, ownerBytes []byte) error {
+	objectName, ownerPrimary, err := i.namespace.parseOwner(ownerBytes)
+	if err != nil {
+		return err
+	}
+	return &UniqueViolation{
+		Object:          objectName,
+		Index:           i.namespace.name,
+		Value:           key,
+		ExistingPrimary: ownerPrimary,
+	}
+}
+
+// count will add value to counters of every prefix of index key, including empty one (total),
+// geo index counts objects for every prefix of the geohash keyed by precision inside the partition
+func (i *Index) count(tr fdb.Transaction, key tuple.Tuple, value []byte) {
+	if i.Geo != 0 {
+		partition := key[:len(key)-1]
+		hash, _ := key[len(key)-1].(string)
+		for n := 0; n <= len(hash); n++ {
+			tr.Add(i.countDir.Sub(partition...).Pack(tuple.Tuple{int64(n), hash[:n]}), value)
+		}
+		return
+	}
+	for n := 0; n <= len(key); n++ {
+		tr.Add(i.countDir.Pack(key[:n]), value)
+	}
+}
+
 // Delete removes selected index
 func (i *Index) Delete(tr fdb.Transaction, primaryTuple tuple.Tuple, key tuple.Tuple) {
 	if key == nil {
@@ -217,37 +375,120 @@ func (i *Index) Delete(tr fdb.Transaction, primaryTuple tuple.Tuple, key tuple.T
 		sub = sub.Sub(primaryTuple...)
 		tr.Clear(sub) // removing old keys
 	}
+	if i.counted {
+		i.count(tr, key, countDec)
+	}
 }
 
-func (i *Index) getIterator(tr fdb.ReadTransaction, q *Query) (subspace.Subspace, *fdb.RangeIterator) {
-	if i.Unique {
-		i.object.panic("index is unique (lists not supported)")
+// encodeTuple will convert query values starting from offset position of the index key to the
+// form stored inside the index (collation and descending order)
+func (i *Index) encodeTuple(offset int, values tuple.Tuple) tuple.Tuple {
+	if i.handle != nil || i.Geo != 0 || values == nil {
+		return values
 	}
-	//if len(q.primary) != 0 {
-	sub := i.dir.Sub(q.primary...)
+	res := make(tuple.Tuple, len(values))
+	for k, value := range values {
+		if offset+k < len(i.fields) {
+			field := i.fields[offset+k]
+			if field.Kind == reflect.Uint8 {
+				if bytes, ok := value.([]byte); ok && len(bytes) == 1 {
+					value = bytes[0]
+				}
+			}
+			res[k] = field.indexElement(value)
+		} else {
+			res[k] = value
+		}
+	}
+	return res
+}
+
+// getRange return key range of index selected by query
+func (i *Index) getRange(q *Query) (subspace.Subspace, fdb.KeyRange) {
+	prefix := i.encodeTuple(0, q.primary)
+	from := i.encodeTuple(len(prefix), q.from)
+	to := i.encodeTuple(len(prefix), q.to)
+	sub := i.dir.Sub(prefix...)
 	start, end := sub.FDBRangeKeys()
-	if q.from != nil {
-		//start = sub.Sub(q.from...)
+	if from != nil {
 		if q.reverse {
-			end = sub.Pack(q.from)
+			end = sub.Pack(from)
 		} else {
-			start = sub.Pack(q.from)
+			start = sub.Pack(from)
 		}
-		if q.to != nil {
+		if to != nil {
 			if q.reverse {
-				start = sub.Pack(q.to)
+				start = sub.Pack(to)
 			} else {
-				end = sub.Pack(q.to)
+				end = sub.Pack(to)
 			}
 		}
 	}
 
-	r := fdb.KeyRange{Begin: start, End: end}
+	return sub, fdb.KeyRange{Begin: start, End: end}
+}
+
+func (i *Index) getIterator(tr fdb.ReadTransaction, q *Query) (subspace.Subspace, *fdb.RangeIterator) {
+	if i.Unique {
+		i.object.panic("index is unique (lists not supported)")
+	}
+	sub, r := i.getRange(q)
 	rangeResult := tr.GetRange(r, fdb.RangeOptions{Mode: fdb.StreamingModeWantAll, Limit: q.limit, Reverse: q.reverse})
 	iterator := rangeResult.Iterator()
 	return sub, iterator
 }
 
+// getCount will count index keys selected by query, counters are used if query is prefix only
+func (i *Index) getCount(tr fdb.ReadTransaction, q *Query) (int64, error) {
+	if counter := i.countKey(q); counter != nil && q.from == nil && q.to == nil {
+		bytes, err := tr.Get(counter).Get()
+		if err != nil {
+			return 0, err
+		}
+		if len(bytes) == 0 {
+			return 0, nil
+		}
+		count := ToInt64(bytes)
+		if count < 0 {
+			count = 0
+		}
+		return count, nil
+	}
+	_, r := i.getRange(q)
+	rows, err := tr.GetRange(r, fdb.RangeOptions{Mode: fdb.StreamingModeWantAll}).GetSliceWithError()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
+
+// countKey return key of the counter for the query prefix, nil if there is no such counter.
+// Geo index counters are keyed by partition, precision and geohash prefix
+func (i *Index) countKey(q *Query) fdb.Key {
+	if !i.counted {
+		return nil
+	}
+	if i.Geo == 0 {
+		return i.countDir.Pack(i.encodeTuple(0, q.primary))
+	}
+	if len(q.primary) < len(i.partition) || len(q.primary) > len(i.partition)+1 {
+		return nil
+	}
+	partition := []interface{}{}
+	for _, element := range q.primary[:len(i.partition)] {
+		partition = append(partition, element)
+	}
+	sub := i.countDir.Sub(i.encodePartition(partition)...)
+	if len(q.primary) == len(i.partition) {
+		return sub.Pack(tuple.Tuple{int64(0), ""})
+	}
+	hash, ok := q.primary[len(i.partition)].(string)
+	if !ok || len(hash) > i.Geo {
+		return nil
+	}
+	return sub.Pack(tuple.Tuple{int64(len(hash)), hash})
+}
+
 // getList will fetch and request all the objects using the index
 func (i *Index) getList(tr fdb.ReadTransaction, q *Query) ([]*needObject, error) {
 	sub, iterator := i.getIterator(tr, q)
@@ -265,15 +506,112 @@ func (i *Index) getList(tr fdb.ReadTransaction, q *Query) ([]*needObject, error)
 			return nil, err
 		}
 		if len(fullTuple)-primaryLen < 0 {
-			return nil, errors.New("invalid data: key too short")
+			return nil, &CorruptionError{Key: kv.Key, Reason: "index key too short"}
 		}
 		key := fullTuple[len(fullTuple)-primaryLen:]
 
-		values = append(values, i.object.need(tr, i.object.sub(key)))
+		values = append(values, i.object.needFields(tr, i.object.sub(key), q.projection))
 	}
 	return values, nil
 }
 
+// coverValue return value of index key containing covered fields
+func (i *Index) coverValue(input *Struct) []byte {
+	if i.covered == nil {
+		return []byte{}
+	}
+	value := tuple.Tuple{}
+	for _, field := range i.covered {
+		value = append(value, field.Name, input.GetBytes(field))
+	}
+	return value.Pack()
+}
+
+// uses return true if the index should be rewritten once the field is changed, covered fields
+// are stored inside the index as well and partition fields prefix its keys
+func (i *Index) uses(field *Field) bool {
+	for _, indexField := range i.fields {
+		if indexField == field {
+			return true
+		}
+	}
+	for _, coveredField := range i.covered {
+		if coveredField == field {
+			return true
+		}
+	}
+	for _, partitionField := range i.partition {
+		if partitionField == field {
+			return true
+		}
+	}
+	return false
+}
+
+// covers return true if all passed fields could be fetched from the index value
+func (i *Index) covers(fields []*Field) bool {
+	if i.covered == nil || i.Unique {
+		return false
+	}
+	for _, field := range fields {
+		found := false
+		for _, coveredField := range i.covered {
+			if coveredField == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// getCoveredList will fetch objects using only the index data, filling the passed fields
+func (i *Index) getCoveredList(tr fdb.ReadTransaction, q *Query, fields []*Field) (*Slice, error) {
+	sub, iterator := i.getIterator(tr, q)
+
+	primaryLen := len(i.object.primaryFields)
+	slice := Slice{values: []*Value{}}
+	for iterator.Advance() {
+		kv, err := iterator.Get()
+		if err != nil {
+			return nil, err
+		}
+		fullTuple, err := sub.Unpack(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(fullTuple)-primaryLen < 0 {
+			return nil, &CorruptionError{Key: kv.Key, Reason: "index key too short"}
+		}
+		covered, err := tuple.Unpack(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		value := Value{object: i.object, raw: valueRaw{}}
+		for k := 0; k+1 < len(covered); k += 2 {
+			fieldName, ok := covered[k].(string)
+			if !ok {
+				return nil, &CorruptionError{Key: kv.Key, Reason: "covered field name is not string"}
+			}
+			data, ok := covered[k+1].([]byte)
+			if !ok {
+				return nil, &CorruptionError{Key: kv.Key, Reason: "covered field value is not bytes"}
+			}
+			for _, field := range fields {
+				if field.Name == fieldName && len(data) > 0 {
+					value.raw[fieldName] = data
+				}
+			}
+		}
+		value.fromKeyTuple(fullTuple[len(fullTuple)-primaryLen:])
+		slice.Append(&value)
+	}
+	return &slice, nil
+}
+
 // getPrimariesList will fetch just an list of primaries
 func (i *Index) getPrimariesList(tr fdb.ReadTransaction, q *Query) (*Slice, error) {
 	sub, iterator := i.getIterator(tr, q)
@@ -290,7 +628,7 @@ func (i *Index) getPrimariesList(tr fdb.ReadTransaction, q *Query) (*Slice, erro
 			return nil, err
 		}
 		if len(fullTuple)-primaryLen < 0 {
-			return nil, errors.New("invalid data: key too short")
+			return nil, &CorruptionError{Key: kv.Key, Reason: "index key too short"}
 		}
 		key := fullTuple[len(fullTuple)-primaryLen:]
 		value := Value{object: i.object}
@@ -302,34 +640,54 @@ func (i *Index) getPrimariesList(tr fdb.ReadTransaction, q *Query) (*Slice, erro
 }
 
 func (i *Index) getPrimary(tr fdb.ReadTransaction, indexKey tuple.Tuple) (subspace.Subspace, error) {
+	return i.needPrimary(tr, indexKey)()
+}
+
+// needPrimary will request primary of the object using index key, returned function waits for the result,
+// so several primaries could be requested at once
+func (i *Index) needPrimary(tr fdb.ReadTransaction, indexKey tuple.Tuple) func() (subspace.Subspace, error) {
 	sub := i.dir.Sub(indexKey...)
 	if i.Unique {
-		bytes, err := tr.Get(sub).Get()
-		if err != nil {
-			return nil, err
-		}
-		if len(bytes) == 0 {
-			return nil, ErrNotFound
+		future := tr.Get(sub)
+		return func() (subspace.Subspace, error) {
+			bytes, err := future.Get()
+			if err != nil {
+				return nil, err
+			}
+			if len(bytes) == 0 {
+				return nil, &NotFoundError{Object: i.object.name}
+			}
+			if i.namespace != nil {
+				objectName, primaryTuple, err := i.namespace.parseOwner(bytes)
+				if err != nil {
+					return nil, err
+				}
+				if objectName != i.object.name { // value is owned by another object
+					return nil, &NotFoundError{Object: i.object.name}
+				}
+				return i.object.primary.Sub(primaryTuple...), nil
+			}
+			primaryTuple, err := tuple.Unpack(bytes)
+			if err != nil {
+				return nil, err
+			}
+			return i.object.primary.Sub(primaryTuple...), nil
 		}
-		primaryTuple, err := tuple.Unpack(bytes)
+	}
+
+	future := tr.GetKey(fdb.FirstGreaterThan(sub))
+	return func() (subspace.Subspace, error) {
+		primaryKey, err := future.Get()
 		if err != nil {
 			return nil, err
 		}
+		primaryTuple, err := sub.Unpack(primaryKey)
+		//primary, err := UnpackKeyIndex(indexKey, primaryKey)
+		if err != nil || len(primaryTuple) < 1 {
+			return nil, &NotFoundError{Object: i.object.name}
+		}
 		return i.object.primary.Sub(primaryTuple...), nil
 	}
-
-	sel := fdb.FirstGreaterThan(sub)
-	primaryKey, err := tr.GetKey(sel).Get()
-	if err != nil {
-		return nil, err
-	}
-	primaryTuple, err := sub.Unpack(primaryKey)
-	//primary, err := UnpackKeyIndex(indexKey, primaryKey)
-	if err != nil || len(primaryTuple) < 1 {
-		return nil, ErrNotFound
-	}
-
-	return i.object.primary.Sub(primaryTuple...), nil
 }
 
 // ReindexUnsafe will update index info (NOT consistency safe function)
@@ -348,14 +706,156 @@ func (i *Index) ReindexUnsafe(data interface{}) *PromiseErr {
 	return p
 }
 
+// clearShared will remove own data of the object from index shared with other objects
+func (i *Index) clearShared() error {
+	if i.global != nil { // search index is shared, so only own postings should be removed
+		return i.global.clearObject(i.object)
+	}
+	if i.namespace != nil { // namespace is shared, so only own values should be removed
+		return i.namespace.clearObject(i.object)
+	}
+	return nil
+}
+
+// clearPaged will call clear for each key of the range, keys are read by batches of separate transactions
+func clearPaged(db *fdb.Database, r fdb.ExactRange, clear func(tr fdb.Transaction, row fdb.KeyValue) error) error {
+	begin, end := r.FDBRangeKeys()
+	for {
+		res, err := db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+			rows, err := tr.GetRange(fdb.KeyRange{Begin: begin, End: end}, fdb.RangeOptions{
+				Limit: clearBatch,
+			}).GetSliceWithError()
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				err = clear(tr, row)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return rows, nil
+		})
+		if err != nil {
+			return err
+		}
+		rows := res.([]fdb.KeyValue)
+		if len(rows) < clearBatch {
+			return nil
+		}
+		begin = fdb.Key(append(append([]byte{}, rows[len(rows)-1].Key...), 0x00))
+	}
+}
+
 func (i *Index) doClearAll(tr fdb.Transaction) {
+	if i.global != nil || i.namespace != nil { // shared data is removed by clearShared
+		return
+	}
 	start, end := i.dir.FDBRangeKeys()
 	tr.ClearRange(fdb.KeyRange{Begin: start, End: end})
+	if i.versionstamp {
+		tr.ClearRange(i.valueDir)
+	}
+	if i.vectorDims != 0 {
+		tr.ClearRange(i.valueDir)
+		tr.ClearRange(i.centroidDir)
+	}
+	if i.search {
+		tr.ClearRange(i.statsDir)
+	}
+	if i.fuzzy {
+		tr.ClearRange(i.trigramDir)
+	}
+	if i.counted {
+		start, end = i.countDir.FDBRangeKeys()
+		tr.ClearRange(fdb.KeyRange{Begin: start, End: end})
+	}
+}
+
+func (i *Index) facet(field *Field, element tuple.TupleElement, count int64) Facet {
+	if field != nil {
+		element = field.indexValue(element)
+	}
+	return Facet{Value: element, Count: count}
+}
+
+// Distinct will return each distinct value of the index field following the prefix, with number of
+// objects having this value. Counted indexes will read counters instead of index keys, except geo
+// indexes which count geohash cells instead of values
+func (i *Index) Distinct(prefix ...interface{}) *PromiseFacets {
+	prefixTuple := tuple.Tuple{}
+	for _, v := range prefix {
+		switch t := v.(type) {
+		case byte:
+			prefixTuple = append(prefixTuple, []byte{t})
+		default:
+			prefixTuple = append(prefixTuple, t)
+		}
+	}
+	prefixTuple = i.encodeTuple(0, prefixTuple)
+	var field *Field
+	if i.handle == nil && i.Geo == 0 && len(prefixTuple) < len(i.fields) {
+		field = i.fields[len(prefixTuple)]
+	}
+	counted := i.counted && i.Geo == 0
+	p := i.object.promiseFacets()
+	p.doRead(func() Chain {
+		var sub subspace.Subspace
+		if counted {
+			sub = i.countDir.Sub(prefixTuple...)
+		} else {
+			sub = i.dir.Sub(prefixTuple...)
+		}
+		start, end := sub.FDBRangeKeys()
+		rangeGet := p.readTr.GetRange(fdb.KeyRange{Begin: start, End: end}, fdb.RangeOptions{
+			Mode: fdb.StreamingModeWantAll,
+		})
+		return func() Chain {
+			rows, err := rangeGet.GetSliceWithError()
+			if err != nil {
+				return p.fail(err)
+			}
+			facets := []Facet{}
+			var last tuple.TupleElement
+			for _, row := range rows {
+				key, err := sub.Unpack(row.Key)
+				if err != nil {
+					return p.fail(err)
+				}
+				if len(key) == 0 {
+					continue
+				}
+				if counted {
+					if len(key) != 1 { // counters of deeper prefixes
+						continue
+					}
+					count := ToInt64(row.Value)
+					if count <= 0 { // all objects having the value were removed
+						continue
+					}
+					facets = append(facets, i.facet(field, key[0], count))
+					continue
+				}
+				if len(facets) != 0 && reflect.DeepEqual(last, key[0]) {
+					facets[len(facets)-1].Count++
+					continue
+				}
+				last = key[0]
+				facets = append(facets, i.facet(field, key[0], 1))
+			}
+			return p.done(facets)
+		}
+	})
+	return p
 }
 
 // ClearAll will remove all data for specific index
 func (i *Index) ClearAll() error {
-	_, err := i.object.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
+	err := i.clearShared()
+	if err != nil {
+		return err
+	}
+	_, err = i.object.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		i.doClearAll(tr)
 		return
 	})
@@ -412,6 +912,12 @@ func (i *Index) SetOption(option IndexOption) {
 	if option.CheckHandler != nil {
 		i.checkHandler = option.CheckHandler
 	}
+	if option.Analyzer != nil {
+		i.analyzer = option.Analyzer
+	}
+	if option.Fuzzy {
+		i.fuzzy = true
+	}
 }
 
 // Options allow to set list of options
@@ -1,6 +1,9 @@
 package stored
 
 import (
+	"errors"
+	"sort"
+
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/mmcloughlin/geohash"
 )
@@ -10,8 +13,9 @@ type IndexGeo struct {
 	index *Index
 }
 
-// GetGeo will return elements by geo index
-func (ig *IndexGeo) GetGeo(lat float64, long float64) *PromiseSlice {
+// GetGeo will return elements by geo index: objects inside the geohash cell of the point and
+// 8 neighbouring cells, Limit is applied to each cell. Partitioned index should receive partition values
+func (ig *IndexGeo) GetGeo(lat float64, long float64, partition ...interface{}) *PromiseSlice {
 	i := ig.index
 	// Precisions
 	// #   km
@@ -26,20 +30,23 @@ func (ig *IndexGeo) GetGeo(lat float64, long float64) *PromiseSlice {
 	// 9   ± 0.0024
 	// 10  ± 0.00060
 	// 11  ± 0.000074
-	hash := geohash.Encode(lat, long)
-	if i.Geo > 0 && i.Geo < len(hash) {
-		hash = hash[0:i.Geo] // cut hash len to match percision of index
+	precision := i.Geo
+	if precision > 12 {
+		precision = 12
 	}
-	neighbors := geohash.Neighbors(hash)
-	search := append(neighbors, hash)
+	search := geoNeighbors(lat, long, precision)
+	partitionTuple := i.encodePartition(partition)
 	p := i.object.promiseSlice()
 	p.doRead(func() Chain {
-		rangeResults := map[string]fdb.RangeResult{}
-		for _, geohash := range search {
-			sub := i.dir.Sub(geohash)
+		if len(partitionTuple) != len(i.partition) {
+			return p.fail(ErrPartitionRequired)
+		}
+		rangeResults := make([]fdb.RangeResult, len(search))
+		for k, geohash := range search {
+			sub := i.dir.Sub(partitionTuple...).Sub(geohash)
 			start, end := sub.FDBRangeKeys()
 			r := fdb.KeyRange{Begin: start, End: end}
-			rangeResults[geohash] = p.readTr.GetRange(r, fdb.RangeOptions{
+			rangeResults[k] = p.readTr.GetRange(r, fdb.RangeOptions{
 				Limit:   p.limit,
 				Reverse: !p.reverse, // select opposite reverse
 			})
@@ -47,23 +54,17 @@ func (ig *IndexGeo) GetGeo(lat float64, long float64) *PromiseSlice {
 		need := []*needObject{}
 		slice := Slice{}
 		return func() Chain {
-			for geohash, res := range rangeResults {
-				sub := i.dir.Sub(geohash)
+			for k, res := range rangeResults {
+				sub := i.dir.Sub(partitionTuple...).Sub(search[k])
 				rows, err := res.GetSliceWithError()
 				if err != nil {
 					return p.fail(err)
 				}
-				if len(rows) == 0 {
-					continue
-				}
-				//fmt.Println("[A] hash got:", sub.Bytes(), "len:", len(rows))
 				for _, row := range rows {
 					primaryTuple, err := sub.Unpack(row.Key)
 					if err != nil {
 						return p.fail(err)
 					}
-					//fmt.Println("[A] primary:", primaryTuple)
-					// need object here
 					need = append(need, i.object.need(p.readTr, i.object.sub(primaryTuple)))
 				}
 			}
@@ -72,12 +73,136 @@ func (ig *IndexGeo) GetGeo(lat float64, long float64) *PromiseSlice {
 					val, err := n.fetch()
 					if err != nil {
 						continue
-						//return p.fail(err)
 					}
 					slice.Append(val)
 				}
-				return p.done(&slice) // return frinish slice here
+				return p.done(&slice)
+			}
+		}
+	})
+	return p
+}
+
+// Query will return geo query: objects inside the geohash cell of the point and 8 neighbouring cells
+// ordered by distance, use Radius to select objects within the distance. Limit is applied to all results
+func (ig *IndexGeo) Query(lat float64, long float64) *GeoQuery {
+	return &GeoQuery{
+		index: ig.index,
+		lat:   lat,
+		long:  long,
+		limit: 100,
+	}
+}
+
+// Nearest will return k objects closest to the point, nearest first, Limit does not change k.
+// Ring around the point is expanded limited number of times, so for sparse data fewer objects
+// could be returned
+func (ig *IndexGeo) Nearest(lat float64, long float64, k int) *GeoQuery {
+	return &GeoQuery{
+		index:   ig.index,
+		lat:     lat,
+		long:    long,
+		limit:   k,
+		nearest: k,
+	}
+}
+
+// Within will return objects inside the box, like map viewport, ordered by distance from
+// the center of the box
+func (ig *IndexGeo) Within(box GeoBox) *GeoQuery {
+	lat, long := box.center()
+	return &GeoQuery{
+		index: ig.index,
+		lat:   lat,
+		long:  long,
+		limit: 100,
+		box:   &box,
+	}
+}
+
+// WithinPolygon will return objects inside the polygon, ordered by distance from the center
+// of the polygon bounding box
+func (ig *IndexGeo) WithinPolygon(polygon []GeoPoint) *GeoQuery {
+	if len(polygon) < 3 {
+		panic("polygon should have at least 3 points")
+	}
+	query := ig.Within(geoPolygonBox(polygon))
+	query.polygon = polygon
+	return query
+}
+
+// Partition will put values of the fields before the geohash inside the index keys, so query
+// could read objects of one partition only, like places of one category: Query(lat, long).Where(category)
+func (ig *IndexGeo) Partition(fieldNames ...string) *IndexGeo {
+	o := ig.index.object
+	for _, name := range fieldNames {
+		field, ok := o.fields[name]
+		if !ok {
+			o.panic("has no key «" + name + "» could not set geo partition")
+		}
+		ig.index.partition = append(ig.index.partition, field)
+	}
+	return ig
+}
+
+// Counted will make index maintain number of objects inside each geohash cell of every precision
+// up to the index precision, so Clusters could be answered without fetching objects
+func (ig *IndexGeo) Counted() *IndexGeo {
+	ig.index.counted = true
+	return ig
+}
+
+// Clusters will return number of objects for each geohash cell of the precision intersecting the box,
+// Facet.Value is the geohash of the cell. Index should be Counted, partitioned index should receive
+// partition values
+func (ig *IndexGeo) Clusters(box GeoBox, precision int, partition ...interface{}) *PromiseFacets {
+	i := ig.index
+	if precision < 1 || precision > i.Geo {
+		precision = i.Geo
+	}
+	partitionTuple := i.encodePartition(partition)
+	p := i.object.promiseFacets()
+	p.doRead(func() Chain {
+		if !i.counted {
+			return p.fail(errors.New("geo index is not counted"))
+		}
+		if len(partitionTuple) != len(i.partition) {
+			return p.fail(ErrPartitionRequired)
+		}
+		sub := i.countDir.Sub(partitionTuple...).Sub(int64(precision))
+		rangeResults := []fdb.RangeResult{}
+		for _, cell := range geoCover(box, precision) {
+			rangeResults = append(rangeResults, p.readTr.GetRange(searchPrefixRange(sub, cell), fdb.RangeOptions{
+				Mode: fdb.StreamingModeWantAll,
+			}))
+		}
+		return func() Chain {
+			facets := []Facet{}
+			for _, res := range rangeResults {
+				rows, err := res.GetSliceWithError()
+				if err != nil {
+					return p.fail(err)
+				}
+				for _, row := range rows {
+					count := ToInt64(row.Value)
+					if count <= 0 { // all objects moved out of the cell
+						continue
+					}
+					key, err := sub.Unpack(row.Key)
+					if err != nil {
+						return p.fail(err)
+					}
+					hash, _ := key[0].(string)
+					if !box.intersects(geohash.BoundingBox(hash)) {
+						continue
+					}
+					facets = append(facets, Facet{Value: hash, Count: count})
+				}
 			}
+			sort.SliceStable(facets, func(a, b int) bool {
+				return facets[a].Value.(string) < facets[b].Value.(string)
+			})
+			return p.done(facets)
 		}
 	})
 	return p
@@ -2,6 +2,9 @@ package stored
 
 import (
 	"errors"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
 	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
@@ -13,84 +16,89 @@ type IndexSearch struct {
 	index *Index
 }
 
-// Search is main function to search using search index
+// Partition will prepend values of the fields to the keys of search index, so search could be
+// restricted to objects having same values, like messages of one chat: Query(query).In(chatID)
+func (is *IndexSearch) Partition(fieldNames ...string) *IndexSearch {
+	o := is.index.object
+	for _, name := range fieldNames {
+		field, ok := o.fields[name]
+		if !ok {
+			o.panic("has no key «" + name + "» could not set search partition")
+		}
+		is.index.partition = append(is.index.partition, field)
+	}
+	return is
+}
+
+// Search is main function to search using search index, objects containing all the words
+// of the query are returned best-first, the last word is matched as prefix. Use Query for scores,
+// partitions and other options
 func (is *IndexSearch) Search(name string) *PromiseSlice {
-	words := searchSplit(name)
+	sq := is.Query(name).Prefix()
+	p := is.index.object.promiseSlice()
+	p.doRead(func() Chain {
+		return sq.run(&p.Promise, p.limit, p.doneHits)
+	})
+	return p
+}
 
-	//word := words[0]
+// Suggest will return most frequent indexed terms starting with the prefix with number
+// of objects containing each term. Terms are returned in the form produced by the analyzer
+// partitioned search index should receive partition values. Suggestion is an approximation:
+// only first searchSuggestScan terms following the prefix in lexicographic order are ranked,
+// so for short prefixes frequent terms could be missed until more characters are typed
+func (is *IndexSearch) Suggest(prefix string, limit int, partition ...interface{}) *PromiseFacets {
 	i := is.index
-	p := i.object.promiseSlice()
-	wordsLen := len(words)
+	partitionTuple := i.encodePartition(partition)
+	terms := i.searchAnalyzer().Analyze(prefix)
+	if len(terms) != 0 {
+		prefix = terms[len(terms)-1] // suggest the word user is typing
+	} else {
+		prefix = strings.ToLower(strings.TrimSpace(prefix))
+	}
+	p := i.object.promiseFacets()
 	p.doRead(func() Chain {
-		if wordsLen < 1 {
-			return p.fail(errors.New("No words found on the string"))
+		if len(partitionTuple) != len(i.partition) {
+			return p.fail(ErrPartitionRequired)
 		}
-
-		limit := 1000
-		if wordsLen == 1 {
-			limit = p.limit
-		}
-		rangeResults := map[string]fdb.RangeResult{}
-		for _, word := range words {
-			partword := i.dir.Pack(tuple.Tuple{word})
-			start := partword[:len(partword)-1]
-			end := make(fdb.Key, len(start))
-			copy(end, start)
-
-			start = append(start, 0)
-			end = append(end, 255)
-
-			r := fdb.KeyRange{Begin: start, End: end}
-			rangeResults[word] = p.readTr.GetRange(r, fdb.RangeOptions{
-				Limit:   limit,
-				Reverse: p.reverse,
-			})
+		sub := i.statsDir.Sub(searchStatsTerm).Sub(partitionTuple...)
+		r := searchPrefixRange(sub, prefix)
+		rows, err := p.readTr.GetRange(r, fdb.RangeOptions{Limit: searchSuggestScan}).GetSliceWithError()
+		if err != nil {
+			return p.fail(err)
 		}
-		need := []*needObject{}
-		slice := Slice{}
-		return func() Chain {
-			found := map[string]int{}
-			for _, word := range words {
-				rows, err := rangeResults[word].GetSliceWithError()
-				if err != nil {
-					return p.fail(err)
-				}
-				for _, row := range rows {
-					fullTuple, err := i.dir.Unpack(row.Key)
-					if err != nil {
-						return p.fail(err)
-					}
-					if len(fullTuple) < 2 {
-						continue
-					}
-					primaryTuple := fullTuple[1:]
-					primaryTupleKey := string(primaryTuple.Pack())
-					_, ok := found[primaryTupleKey]
-					if ok {
-						found[primaryTupleKey]++
-					} else {
-						found[primaryTupleKey] = 1
-					}
-					if found[primaryTupleKey] == wordsLen {
-						need = append(need, i.object.need(p.readTr, i.object.sub(primaryTuple)))
-					}
-				}
+		facets := []Facet{}
+		for _, row := range rows {
+			count := ToInt64(row.Value)
+			if count <= 0 { // term was removed from all the objects
+				continue
 			}
-			return func() Chain {
-				for _, n := range need {
-					val, err := n.fetch()
-					if err != nil {
-						continue
-					}
-					slice.Append(val)
-				}
-				return p.done(&slice) // return frinish slice here
+			termTuple, err := sub.Unpack(row.Key)
+			if err != nil {
+				return p.fail(err)
 			}
+			facets = append(facets, Facet{Value: termTuple[0], Count: count})
+		}
+		sort.SliceStable(facets, func(a, b int) bool {
+			return facets[a].Count > facets[b].Count
+		})
+		if limit > 0 && len(facets) > limit {
+			facets = facets[:limit]
 		}
+		return p.done(facets)
 	})
 	return p
 }
 
+// SuggestObjects will return top objects for the term returned by Suggest
+func (is *IndexSearch) SuggestObjects(term string, limit int) *SearchQuery {
+	return &SearchQuery{
+		index: is.index,
+		terms: []string{term}, // term is already analyzed
+		limit: limit,
+	}
+}
+
 // ClearAll will remove index data
 func (is *IndexSearch) ClearAll() error {
 	return is.index.ClearAll()
@@ -100,3 +108,127 @@ func (is *IndexSearch) ClearAll() error {
 func (is *IndexSearch) Reindex() {
 	is.index.Reindex()
 }
+
+// ClearIn will remove index data of the partition
+func (is *IndexSearch) ClearIn(partition ...interface{}) error {
+	i := is.index
+	partitionTuple := i.encodePartition(partition)
+	if len(partitionTuple) != len(i.partition) {
+		return ErrPartitionRequired
+	}
+	_, err := i.clearIn(partitionTuple)
+	return err
+}
+
+// clearIn will remove index data of the partition and return primaries of objects it had, keys
+// are removed by batches of separate transactions, since partition could be big
+func (i *Index) clearIn(partitionTuple tuple.Tuple) ([]tuple.Tuple, error) {
+	sub := i.dir.Sub(partitionTuple...)
+	primaries := []tuple.Tuple{}
+	cleared := map[string]bool{}
+	err := clearPaged(i.object.db, sub, func(tr fdb.Transaction, row fdb.KeyValue) error {
+		fullTuple, err := sub.Unpack(row.Key)
+		if err != nil {
+			return err
+		}
+		tr.Clear(row.Key)
+		primaryTuple := fullTuple[1:]
+		// length key is cleared by the first posting of the object, next ones change nothing
+		_, err = i.writeSearchLength(tr, primaryTuple, false, 0)
+		if err != nil {
+			return err
+		}
+		primaryKey := string(primaryTuple.Pack())
+		if !cleared[primaryKey] { // batch could be retried, so object is remembered once
+			cleared[primaryKey] = true
+			primaries = append(primaries, primaryTuple)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	clear := func(tr fdb.Transaction, row fdb.KeyValue) error {
+		tr.Clear(row.Key)
+		return nil
+	}
+	err = clearPaged(i.object.db, i.statsDir.Sub(searchStatsTerm).Sub(partitionTuple...), clear)
+	if err != nil || !i.fuzzy {
+		return primaries, err
+	}
+	return primaries, clearPaged(i.object.db, i.trigramDir.Sub(partitionTuple...), clear)
+}
+
+// ReindexIn will reindex objects of the partition. Objects are listed by the index starting with
+// partition fields if object has one, otherwise objects are found by keys of the partition inside
+// the search index, so only objects indexed before are reindexed
+func (is *IndexSearch) ReindexIn(partition ...interface{}) error {
+	i := is.index
+	partitionTuple := i.encodePartition(partition)
+	if len(partitionTuple) != len(i.partition) {
+		return ErrPartitionRequired
+	}
+	primaries, err := i.clearIn(partitionTuple)
+	if err != nil {
+		return err
+	}
+	object := i.object
+	write := func(primaryTuple tuple.Tuple, input *Struct) {
+		_, e := object.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+			return nil, i.Write(tr, primaryTuple, input, nil)
+		})
+		if e != nil {
+			err = e
+		}
+	}
+	if index := i.partitionIndex(); index != nil {
+		query := object.Use(index.Name).List(partition...).Limit(100)
+		for query.Next() {
+			query.Slice().Each(func(item interface{}) {
+				input := structAny(item)
+				write(input.getPrimary(object), input)
+			})
+		}
+		return err
+	}
+	for _, primaryTuple := range primaries {
+		_, e := object.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+			value, err := object.need(tr, object.sub(primaryTuple)).fetch()
+			if errors.Is(err, ErrNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			input := structAny(value.Interface())
+			if !reflect.DeepEqual(i.searchPartition(input), partitionTuple) {
+				return nil, nil
+			}
+			return nil, i.Write(tr, primaryTuple, input, nil)
+		})
+		if e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// partitionIndex return index of the object starting with partition fields of the search index
+func (i *Index) partitionIndex() *Index {
+	for _, index := range i.object.indexes {
+		if index.search || index.Geo != 0 || index.vectorDims != 0 || index.handle != nil || index.namespace != nil ||
+			index.versionstamp || index.checkHandler != nil || len(index.fields) < len(i.partition) {
+			continue
+		}
+		same := true
+		for k, field := range i.partition {
+			if index.fields[k] != field {
+				same = false
+			}
+		}
+		if same {
+			return index
+		}
+	}
+	return nil
+}
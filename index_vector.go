@@ -0,0 +1,408 @@
+package stored
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+const (
+	vectorDefaultLists  = 64    // number of centroids vectors are split by
+	vectorDefaultProbes = 8     // number of lists nearest to the query read by approximate search
+	vectorTrainSample   = 50000 // maximum number of vectors centroids are trained on
+	vectorTrainBatch    = 500   // number of vectors read or moved by one transaction of Train
+)
+
+// vectorVersion is the key inside centroid directory changed each time centroids are changed
+const vectorVersion = "version"
+
+// VectorMetric is similarity function used by vector index
+type VectorMetric int
+
+const (
+	// VectorCosine compares angle between vectors, score is cosine similarity
+	VectorCosine VectorMetric = iota
+	// VectorDot compares vectors by dot product, score is the product
+	VectorDot
+	// VectorEuclidean compares vectors by distance, score is 1/(1+distance)
+	VectorEuclidean
+)
+
+// score return similarity of vectors, bigger is more similar
+func (m VectorMetric) score(a, b []float32) float64 {
+	var dot, normA, normB, distance float64
+	for k := range a {
+		x, y := float64(a[k]), float64(b[k])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		distance += (x - y) * (x - y)
+	}
+	switch m {
+	case VectorDot:
+		return dot
+	case VectorEuclidean:
+		return 1 / (1 + math.Sqrt(distance))
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// IndexVector does all the Index does but also similarity search over embeddings, vectors are
+// split into lists by nearest centroid (IVF). Centroids are seeded by first vectors written, so
+// until Train is called lists follow the order objects were written in and approximate search
+// could miss vectors lying in lists that were not probed. Recall grows with Probes, Exact gives
+// the precise result reading all the vectors
+type IndexVector struct {
+	index *Index
+}
+
+// vectorCache keeps decoded centroids of vector index read by the last transaction, centroids are
+// read again only once version is changed by seeding or training
+type vectorCache struct {
+	version   []byte
+	centroids []vectorCentroid
+	mux       sync.Mutex
+}
+
+// Lists sets number of centroids vectors are split by, should be set before objects are written
+func (iv *IndexVector) Lists(lists int) *IndexVector {
+	iv.index.vectorLists = lists
+	return iv
+}
+
+// Train will compute centroids by k-means over the sample of stored vectors and move vectors to lists
+// of new centroids. Vectors are read and moved by batches of separate transactions, objects could be
+// written meanwhile, but approximate search could miss vectors not moved yet until Train is finished
+func (iv *IndexVector) Train(iterations int) error {
+	i := iv.index
+	sample, err := i.vectorSample()
+	if err != nil {
+		return err
+	}
+	if len(sample) == 0 {
+		return nil
+	}
+	centroids := vectorKMeans(i.vectorMetric, sample, i.vectorLists, iterations)
+	_, err = i.object.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+		tr.ClearRange(i.centroidDir)
+		for _, centroid := range centroids {
+			tr.Set(i.centroidDir.Pack(tuple.Tuple{centroid.list}), vectorEncode(centroid.vector))
+		}
+		tr.Set(i.vectorVersionKey(), vectorVersionNew())
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	return i.vectorMove()
+}
+
+// Nearest will return k objects most similar to the vector, only lists of nearest centroids are read,
+// use Exact to compare with all the vectors
+func (iv *IndexVector) Nearest(vector []float32, k int) *VectorQuery {
+	return &VectorQuery{
+		index:  iv.index,
+		vector: vector,
+		k:      k,
+		probes: vectorDefaultProbes,
+	}
+}
+
+// vectorOf return vector value of the field
+func vectorOf(value interface{}) []float32 {
+	switch v := value.(type) {
+	case []float32:
+		return v
+	case []float64:
+		vector := make([]float32, len(v))
+		for k, x := range v {
+			vector[k] = float32(x)
+		}
+		return vector
+	}
+	return nil
+}
+
+func vectorEncode(vector []float32) []byte {
+	bytes := make([]byte, len(vector)*4)
+	for k, x := range vector {
+		binary.LittleEndian.PutUint32(bytes[k*4:], math.Float32bits(x))
+	}
+	return bytes
+}
+
+func vectorDecode(bytes []byte) []float32 {
+	vector := make([]float32, len(bytes)/4)
+	for k := range vector {
+		vector[k] = math.Float32frombits(binary.LittleEndian.Uint32(bytes[k*4:]))
+	}
+	return vector
+}
+
+// vectorCentroid is the center of the list of vector index
+type vectorCentroid struct {
+	list   int64
+	vector []float32
+}
+
+// vectorCentroids will decode centroids of the index
+func (i *Index) vectorCentroids(rows []fdb.KeyValue) ([]vectorCentroid, error) {
+	centroids := []vectorCentroid{}
+	for _, row := range rows {
+		key, err := i.centroidDir.Unpack(row.Key)
+		if err != nil {
+			return nil, err
+		}
+		if key[0] == vectorVersion {
+			continue
+		}
+		list, ok := key[0].(int64)
+		if !ok {
+			return nil, fmt.Errorf("invalid data: centroid key %v", key)
+		}
+		centroids = append(centroids, vectorCentroid{list: list, vector: vectorDecode(row.Value)})
+	}
+	return centroids, nil
+}
+
+// vectorList return list of the centroid nearest to the vector
+func (i *Index) vectorList(centroids []vectorCentroid, vector []float32) int64 {
+	list := int64(0)
+	best := math.Inf(-1)
+	for _, centroid := range centroids {
+		score := i.vectorMetric.score(vector, centroid.vector)
+		if score > best {
+			best = score
+			list = centroid.list
+		}
+	}
+	return list
+}
+
+func (i *Index) vectorVersionKey() fdb.Key {
+	return i.centroidDir.Pack(tuple.Tuple{vectorVersion})
+}
+
+// vectorVersionNew return random version, so versions never repeat even after index is cleared
+func vectorVersionNew() []byte {
+	return tuple.Tuple{rand.Int63()}.Pack()
+}
+
+// needCentroids will read version of centroids, centroids themselves are read only when version
+// differs from the cached one. Version is read with conflict, so transactions seeding or training
+// centroids conflict with each other and with writes relying on the old centroids
+func (i *Index) needCentroids(tr fdb.ReadTransaction) func() ([]vectorCentroid, error) {
+	versionGet := tr.Get(i.vectorVersionKey())
+	return func() ([]vectorCentroid, error) {
+		version, err := versionGet.Get()
+		if err != nil {
+			return nil, err
+		}
+		cache := i.vectorCache
+		cache.mux.Lock()
+		if cache.centroids != nil && bytes.Equal(cache.version, version) {
+			centroids := cache.centroids
+			cache.mux.Unlock()
+			return centroids, nil
+		}
+		cache.mux.Unlock()
+		rows, err := tr.Snapshot().GetRange(i.centroidDir, fdb.RangeOptions{Mode: fdb.StreamingModeWantAll}).GetSliceWithError()
+		if err != nil {
+			return nil, err
+		}
+		centroids, err := i.vectorCentroids(rows)
+		if err != nil {
+			return nil, err
+		}
+		cache.mux.Lock()
+		cache.version = version
+		cache.centroids = centroids
+		cache.mux.Unlock()
+		return centroids, nil
+	}
+}
+
+// vectorSample will read vectors of the index by batches, at most vectorTrainSample vectors are
+// sampled uniformly
+func (i *Index) vectorSample() ([]vectorCentroid, error) {
+	sample := []vectorCentroid{}
+	seen := 0
+	begin, end := i.dir.FDBRangeKeys()
+	for {
+		res, err := i.object.db.ReadTransact(func(tr fdb.ReadTransaction) (interface{}, error) {
+			return tr.GetRange(fdb.KeyRange{Begin: begin, End: end}, fdb.RangeOptions{Limit: vectorTrainBatch}).GetSliceWithError()
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows := res.([]fdb.KeyValue)
+		for _, row := range rows {
+			vector := vectorCentroid{vector: vectorDecode(row.Value)}
+			seen++
+			if len(sample) < vectorTrainSample {
+				sample = append(sample, vector)
+			} else if n := rand.Intn(seen); n < vectorTrainSample {
+				sample[n] = vector
+			}
+		}
+		if len(rows) < vectorTrainBatch {
+			return sample, nil
+		}
+		begin = fdb.Key(append(append([]byte{}, rows[len(rows)-1].Key...), 0x00))
+	}
+}
+
+// vectorKMeans will split vectors into lists clusters, initial centroids are spread over the sample
+func vectorKMeans(metric VectorMetric, vectors []vectorCentroid, lists, iterations int) []vectorCentroid {
+	if lists > len(vectors) {
+		lists = len(vectors)
+	}
+	centroids := make([]vectorCentroid, lists)
+	for k := range centroids {
+		centroids[k] = vectorCentroid{list: int64(k), vector: vectors[k*len(vectors)/lists].vector}
+	}
+	i := Index{vectorMetric: metric}
+	for n := 0; n < iterations; n++ {
+		sums := make([][]float64, lists)
+		counts := make([]int, lists)
+		for _, vector := range vectors {
+			list := i.vectorList(centroids, vector.vector)
+			if sums[list] == nil {
+				sums[list] = make([]float64, len(vector.vector))
+			}
+			for k, x := range vector.vector {
+				sums[list][k] += float64(x)
+			}
+			counts[list]++
+		}
+		for list := range centroids {
+			if counts[list] == 0 { // empty list keeps its centroid
+				continue
+			}
+			vector := make([]float32, len(sums[list]))
+			for k, sum := range sums[list] {
+				vector[k] = float32(sum / float64(counts[list]))
+			}
+			centroids[list].vector = vector
+		}
+	}
+	return centroids
+}
+
+// vectorMove will move vectors to lists of nearest centroids by batches of transactions
+func (i *Index) vectorMove() error {
+	begin, end := i.valueDir.FDBRangeKeys()
+	for {
+		res, err := i.object.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+			centroidsGet := i.needCentroids(tr)
+			rows, err := tr.GetRange(fdb.KeyRange{Begin: begin, End: end}, fdb.RangeOptions{Limit: vectorTrainBatch}).GetSliceWithError()
+			if err != nil {
+				return nil, err
+			}
+			centroids, err := centroidsGet()
+			if err != nil {
+				return nil, err
+			}
+			primaries := make([]tuple.Tuple, len(rows))
+			lists := make([]tuple.Tuple, len(rows))
+			vectors := make([]fdb.FutureByteSlice, len(rows))
+			for k, row := range rows {
+				primaries[k], err = i.valueDir.Unpack(row.Key)
+				if err != nil {
+					return nil, err
+				}
+				lists[k], err = tuple.Unpack(row.Value)
+				if err != nil {
+					return nil, err
+				}
+				vectors[k] = tr.Get(i.dir.Pack(append(append(tuple.Tuple{}, lists[k]...), primaries[k]...)))
+			}
+			for k, future := range vectors {
+				value, err := future.Get()
+				if err != nil {
+					return nil, err
+				}
+				if value == nil {
+					continue
+				}
+				list := i.vectorList(centroids, vectorDecode(value))
+				if len(lists[k]) == 1 && lists[k][0] == list {
+					continue
+				}
+				tr.Clear(i.dir.Pack(append(append(tuple.Tuple{}, lists[k]...), primaries[k]...)))
+				tr.Set(i.dir.Pack(append(tuple.Tuple{list}, primaries[k]...)), value)
+				tr.Set(i.valueDir.Pack(primaries[k]), tuple.Tuple{list}.Pack())
+			}
+			return rows, nil
+		})
+		if err != nil {
+			return err
+		}
+		rows := res.([]fdb.KeyValue)
+		if len(rows) < vectorTrainBatch {
+			return nil
+		}
+		begin = fdb.Key(append(append([]byte{}, rows[len(rows)-1].Key...), 0x00))
+	}
+}
+
+// writeVector will put vector of the object to the list of nearest centroid, first vectors
+// become centroids until number of lists is reached. Seeding changes centroids version, so
+// concurrent seeding transactions conflict and retry instead of writing the same list
+func (i *Index) writeVector(tr fdb.Transaction, primaryTuple tuple.Tuple, input *Struct) error {
+	err := i.deleteVector(tr, primaryTuple)
+	if err != nil {
+		return err
+	}
+	vector := vectorOf(input.Get(i.fields[0]))
+	if len(vector) == 0 {
+		return nil
+	}
+	if i.checkHandler != nil && !i.checkHandler(input.value.Interface()) {
+		return nil
+	}
+	if len(vector) != i.vectorDims {
+		return fmt.Errorf("vector has %d dimensions instead of %d", len(vector), i.vectorDims)
+	}
+	centroids, err := i.needCentroids(tr)()
+	if err != nil {
+		return err
+	}
+	list := int64(len(centroids))
+	if len(centroids) < i.vectorLists {
+		tr.Set(i.centroidDir.Pack(tuple.Tuple{list}), vectorEncode(vector))
+		tr.Set(i.vectorVersionKey(), vectorVersionNew())
+	} else {
+		list = i.vectorList(centroids, vector)
+	}
+	tr.Set(i.dir.Pack(append(tuple.Tuple{list}, primaryTuple...)), vectorEncode(vector))
+	tr.Set(i.valueDir.Pack(primaryTuple), tuple.Tuple{list}.Pack())
+	return nil
+}
+
+// deleteVector will remove vector of the object from its list
+func (i *Index) deleteVector(tr fdb.Transaction, primaryTuple tuple.Tuple) error {
+	bytes, err := tr.Get(i.valueDir.Pack(primaryTuple)).Get()
+	if err != nil {
+		return err
+	}
+	if bytes == nil {
+		return nil
+	}
+	listTuple, err := tuple.Unpack(bytes)
+	if err != nil {
+		return err
+	}
+	tr.Clear(i.dir.Pack(append(listTuple, primaryTuple...)))
+	tr.Clear(i.valueDir.Pack(primaryTuple))
+	return nil
+}
@@ -0,0 +1,137 @@
+package stored
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+// IndexVersionstamp does all the Index does, but keys are ordered by commit of the transaction
+// which added the object. Position is assigned by FoundationDB so writes does not conflict
+type IndexVersionstamp struct {
+	index *Index
+}
+
+// Cursor will return versionstamp assigned to the object inside the index, it could be passed
+// to Query.From to continue scan starting from this object
+func (iv *IndexVersionstamp) Cursor(objOrID interface{}) *Promise {
+	i := iv.index
+	p := i.object.promise()
+	p.doRead(func() Chain {
+		primaryTuple := i.object.getPrimaryTuple(objOrID)
+		stamp, _, err := i.getVersionstamp(p.readTr, primaryTuple)
+		if err != nil {
+			return p.fail(err)
+		}
+		if stamp == nil {
+			return p.fail(ErrNotFound)
+		}
+		return p.done(*stamp)
+	})
+	return p
+}
+
+// getVersionstamp return versionstamp and the key prefix the object stored with inside the index
+func (i *Index) getVersionstamp(tr fdb.ReadTransaction, primaryTuple tuple.Tuple) (*tuple.Versionstamp, tuple.Tuple, error) {
+	sub := i.valueDir.Sub(primaryTuple...)
+	start, end := sub.FDBRangeKeys()
+	rows, err := tr.GetRange(fdb.KeyRange{Begin: start, End: end}, fdb.RangeOptions{Limit: 1}).GetSliceWithError()
+	if err != nil {
+		if fdbErr, ok := err.(fdb.Error); ok && fdbErr.Code == 1036 { // accessed_unreadable
+			return nil, nil, ErrVersionstampPending
+		}
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+	stampTuple, err := sub.Unpack(rows[0].Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(stampTuple) != 1 {
+		return nil, nil, errors.New("invalid data: versionstamp key")
+	}
+	stamp, ok := stampTuple[0].(tuple.Versionstamp)
+	if !ok {
+		return nil, nil, errors.New("invalid data: versionstamp expected")
+	}
+	prefix, err := tuple.Unpack(rows[0].Value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &stamp, prefix, nil
+}
+
+// versionstampKey return index key for object with known versionstamp
+func (i *Index) versionstampKey(prefix tuple.Tuple, stamp tuple.Versionstamp, primaryTuple tuple.Tuple) fdb.Key {
+	key := append(append(append(tuple.Tuple{}, prefix...), stamp), primaryTuple...)
+	return i.dir.Pack(key)
+}
+
+// writeVersionstamp will add object to the index once, updates keep the object position
+// unless prefix fields changed, then object is moved preserving the versionstamp.
+// Current prefix is also stored under the plain key, so the object could be written again
+// inside the transaction added it, while versionstamped keys are unreadable until commit
+func (i *Index) writeVersionstamp(tr fdb.Transaction, primaryTuple tuple.Tuple, input *Struct) error {
+	prefix := i.getKey(input)
+	bites, err := tr.Get(i.valueDir.Pack(primaryTuple)).Get()
+	if err != nil {
+		return err
+	}
+	if bites != nil {
+		oldPrefix, err := tuple.Unpack(bites)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(oldPrefix, prefix) {
+			return nil
+		}
+	}
+	stamp, oldPrefix, err := i.getVersionstamp(tr, primaryTuple)
+	if err != nil {
+		return err
+	}
+	tr.Set(i.valueDir.Pack(primaryTuple), prefix.Pack())
+	if stamp != nil {
+		if reflect.DeepEqual(oldPrefix, prefix) {
+			return nil
+		}
+		tr.Clear(i.versionstampKey(oldPrefix, *stamp, primaryTuple))
+		tr.Set(i.versionstampKey(prefix, *stamp, primaryTuple), []byte{})
+		tr.Set(i.valueDir.Pack(append(append(tuple.Tuple{}, primaryTuple...), *stamp)), prefix.Pack())
+		return nil
+	}
+	incomplete := tuple.IncompleteVersionstamp(0)
+	key := append(append(append(tuple.Tuple{}, prefix...), incomplete), primaryTuple...)
+	keyBytes, err := key.PackWithVersionstamp(i.dir.Bytes())
+	if err != nil {
+		return err
+	}
+	tr.SetVersionstampedKey(fdb.Key(keyBytes), []byte{})
+	valueKey := append(append(tuple.Tuple{}, primaryTuple...), incomplete)
+	keyBytes, err = valueKey.PackWithVersionstamp(i.valueDir.Bytes())
+	if err != nil {
+		return err
+	}
+	tr.SetVersionstampedKey(fdb.Key(keyBytes), prefix.Pack())
+	return nil
+}
+
+// deleteVersionstamp will remove object from the index, object added inside the same transaction
+// could not be removed since its key is not known until commit
+func (i *Index) deleteVersionstamp(tr fdb.Transaction, primaryTuple tuple.Tuple) error {
+	stamp, prefix, err := i.getVersionstamp(tr, primaryTuple)
+	if err != nil {
+		return err
+	}
+	if stamp == nil {
+		return nil
+	}
+	tr.Clear(i.versionstampKey(prefix, *stamp, primaryTuple))
+	tr.Clear(i.valueDir.Pack(primaryTuple))
+	tr.ClearRange(i.valueDir.Sub(primaryTuple...))
+	return nil
+}
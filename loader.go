@@ -0,0 +1,184 @@
+package stored
+
+import (
+	"sync"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+const (
+	loaderDefaultWait  = time.Millisecond // window Get calls are collected within
+	loaderDefaultBatch = 500              // maximum number of objects fetched by one transaction
+)
+
+// Loader coalesces Get and GetBy calls of the object performed concurrently from different goroutines,
+// calls arrived within the wait window are fetched by one read transaction and same objects are
+// fetched only once
+type Loader struct {
+	object   *Object
+	wait     time.Duration
+	maxBatch int
+	batch    *loaderBatch // batch collecting calls at the moment
+	mux      sync.Mutex
+}
+
+// loaderBatch is the list of calls fetched by one transaction
+type loaderBatch struct {
+	requests map[string]*loaderRequest // requests by primary or index key
+	timer    *time.Timer
+	done     chan struct{} // closed once batch is fetched
+}
+
+// loaderRequest is object requested by one or several calls
+type loaderRequest struct {
+	index *Index      // nil if object is requested using primary
+	key   tuple.Tuple // primary or index key of the object
+	value *Value
+	err   error
+}
+
+// Loader return batching loader of the object, wait is the window calls are collected within and
+// maxBatch is maximum number of objects fetched by one transaction, zero values mean defaults
+func (o *Object) Loader(wait time.Duration, maxBatch int) *Loader {
+	if wait <= 0 {
+		wait = loaderDefaultWait
+	}
+	if maxBatch <= 0 {
+		maxBatch = loaderDefaultBatch
+	}
+	return &Loader{
+		object:   o,
+		wait:     wait,
+		maxBatch: maxBatch,
+	}
+}
+
+// Get fetch object using primary id same way Object.Get does, but together with other calls of the loader
+func (l *Loader) Get(objectPtr interface{}) error {
+	input := structEditable(objectPtr)
+	return l.load(input, nil, input.getPrimary(l.object))
+}
+
+// GetBy fetch one object using index same way Object.GetBy does, but together with other calls of the loader
+func (l *Loader) GetBy(objectPtr interface{}, indexKeys ...string) error {
+	input := structEditable(objectPtr)
+	index := l.object.Index(indexKeys...)
+	return l.load(input, index, index.getKey(input))
+}
+
+// load will add request to the batch, wait till the batch is fetched and fill the object
+func (l *Loader) load(input *Struct, index *Index, key tuple.Tuple) error {
+	id := "p" + string(key.Pack())
+	if index != nil {
+		id = "i" + index.Name + "\x00" + string(key.Pack())
+	}
+
+	l.mux.Lock()
+	batch := l.batch
+	if batch == nil {
+		batch = &loaderBatch{
+			requests: map[string]*loaderRequest{},
+			done:     make(chan struct{}),
+		}
+		l.batch = batch
+		batch.timer = time.AfterFunc(l.wait, func() {
+			l.flush(batch)
+		})
+	}
+	request, ok := batch.requests[id]
+	if !ok {
+		request = &loaderRequest{index: index, key: key}
+		batch.requests[id] = request
+	}
+	full := len(batch.requests) >= l.maxBatch
+	l.mux.Unlock()
+
+	if full {
+		batch.timer.Stop()
+		l.flush(batch)
+	}
+	<-batch.done
+	if request.err != nil {
+		return request.err
+	}
+	input.Fill(l.object, request.value)
+	return nil
+}
+
+// flush will fetch the batch unless it was fetched already
+func (l *Loader) flush(batch *loaderBatch) {
+	l.mux.Lock()
+	if l.batch != batch {
+		l.mux.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mux.Unlock()
+
+	err := l.fetch(batch)
+	if err != nil {
+		for _, request := range batch.requests {
+			request.err = err
+		}
+	}
+	close(batch.done)
+}
+
+// fetch will read all objects of the batch within one transaction, first primaries of objects
+// requested by index are resolved, then all objects are fetched at once
+func (l *Loader) fetch(batch *loaderBatch) error {
+	o := l.object
+	p := o.promise()
+	p.doRead(func() Chain {
+		needed := map[string]*needObject{}
+		subs := map[*loaderRequest]string{}
+		need := func(request *loaderRequest, sub subspace.Subspace) {
+			id := string(sub.Bytes())
+			if _, ok := needed[id]; !ok {
+				needed[id] = o.need(p.readTr, sub)
+			}
+			subs[request] = id
+		}
+		primaries := map[*loaderRequest]func() (subspace.Subspace, error){}
+		for _, request := range batch.requests {
+			request.value, request.err = nil, nil
+			if request.index == nil {
+				need(request, o.sub(request.key))
+			} else {
+				primaries[request] = request.index.needPrimary(p.readTr, request.key)
+			}
+		}
+		for request, primary := range primaries {
+			sub, err := primary()
+			if err != nil {
+				if _, ok := err.(fdb.Error); ok {
+					return p.fail(err)
+				}
+				request.err = err
+				continue
+			}
+			need(request, sub)
+		}
+
+		type fetched struct {
+			value *Value
+			err   error
+		}
+		results := map[string]fetched{}
+		for id, needObject := range needed {
+			value, err := needObject.fetch()
+			if _, ok := err.(fdb.Error); ok {
+				return p.fail(err)
+			}
+			results[id] = fetched{value: value, err: err}
+		}
+		for request, id := range subs {
+			request.value, request.err = results[id].value, results[id].err
+		}
+		return p.done(nil)
+	})
+	return p.Err()
+}
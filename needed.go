@@ -6,9 +6,11 @@ import (
 )
 
 type needObject struct {
-	object      *Object
-	rangeResult fdb.RangeResult
-	subspace    subspace.Subspace
+	object       *Object
+	rangeResult  fdb.RangeResult
+	subspace     subspace.Subspace
+	fields       []*Field // if set only this fields will be fetched
+	fieldResults []fdb.FutureByteSlice
 }
 
 func (n *needObject) need(tr fdb.ReadTransaction, sub subspace.Subspace) {
@@ -17,6 +19,14 @@ func (n *needObject) need(tr fdb.ReadTransaction, sub subspace.Subspace) {
 	//start, end := sub.FDBRangeKeys()
 	//r := fdb.KeyRange{Begin: start, End: end}
 
+	if n.fields != nil {
+		n.fieldResults = make([]fdb.FutureByteSlice, len(n.fields))
+		for k, field := range n.fields {
+			n.fieldResults[k] = tr.Get(field.getKey(sub))
+		}
+		return
+	}
+
 	start := sub.FDBKey()
 	end := append(start, uint8(255))
 	r := fdb.KeyRange{Begin: start, End: end}
@@ -25,15 +35,54 @@ func (n *needObject) need(tr fdb.ReadTransaction, sub subspace.Subspace) {
 }
 
 func (n *needObject) fetch() (*Value, error) {
+	if n.fields != nil {
+		return n.fetchFields()
+	}
 	rows, err := n.rangeResult.GetSliceWithError()
 	if err != nil {
 		return nil, err
 	}
 	if len(rows) == 0 {
-		// problem is here so shouldbe checked
-		return nil, ErrNotFound
+		return nil, n.notFound()
 	}
 	value := Value{object: n.object}
 	value.FromKeyValue(n.subspace, rows)
 	return &value, nil
 }
+
+// fetchFields will return value filled only with requested fields
+func (n *needObject) fetchFields() (*Value, error) {
+	value := Value{object: n.object, raw: valueRaw{}}
+	found := false
+	for k, field := range n.fields {
+		bytes, err := n.fieldResults[k].Get()
+		if err != nil {
+			return nil, err
+		}
+		if bytes == nil {
+			continue
+		}
+		found = true
+		if len(bytes) > 0 {
+			value.raw[field.Name] = bytes
+		}
+	}
+	if !found && len(n.fields) != 0 {
+		return nil, n.notFound()
+	}
+	keysTuple, err := n.object.primary.Unpack(n.subspace.FDBKey())
+	if err != nil {
+		return nil, err
+	}
+	value.fromKeyTuple(keysTuple)
+	return &value, nil
+}
+
+// notFound return error describing the missing object
+func (n *needObject) notFound() error {
+	primary, err := n.object.primary.Unpack(n.subspace.FDBKey())
+	if err != nil {
+		return &CorruptionError{Key: n.subspace.FDBKey(), Reason: err.Error()}
+	}
+	return &NotFoundError{Object: n.object.name, Primary: primary}
+}
@@ -1,6 +1,7 @@
 package stored
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -182,14 +183,18 @@ func (o *Object) doWrite(tr fdb.Transaction, sub subspace.Subspace, primaryTuple
 
 func (o *Object) promise() *Promise {
 	return &Promise{
-		db: o.db,
+		db:         o.db,
+		dir:        o.directory,
+		idempotent: idempotent{object: o.name},
 	}
 }
 
 func (o *Object) promiseSlice() *PromiseSlice {
 	return &PromiseSlice{
 		Promise: Promise{
-			db: o.db,
+			db:         o.db,
+			dir:        o.directory,
+			idempotent: idempotent{object: o.name},
 		},
 		limit: 100,
 	}
@@ -198,7 +203,9 @@ func (o *Object) promiseSlice() *PromiseSlice {
 func (o *Object) promiseErr() *PromiseErr {
 	return &PromiseErr{
 		Promise{
-			db: o.db,
+			db:         o.db,
+			dir:        o.directory,
+			idempotent: idempotent{object: o.name},
 		},
 	}
 }
@@ -206,14 +213,54 @@ func (o *Object) promiseErr() *PromiseErr {
 func (o *Object) promiseValue() *PromiseValue {
 	return &PromiseValue{
 		Promise{
-			db: o.db,
+			db:         o.db,
+			dir:        o.directory,
+			idempotent: idempotent{object: o.name},
+		},
+	}
+}
+
+func (o *Object) promiseFacets() *PromiseFacets {
+	return &PromiseFacets{
+		Promise{
+			db:  o.db,
+			dir: o.directory,
+		},
+	}
+}
+
+func (o *Object) promiseHits() *PromiseHits {
+	return &PromiseHits{
+		Promise{
+			db:  o.db,
+			dir: o.directory,
+		},
+	}
+}
+
+func (o *Object) promiseGeoHits() *PromiseGeoHits {
+	return &PromiseGeoHits{
+		Promise{
+			db:  o.db,
+			dir: o.directory,
+		},
+	}
+}
+
+func (o *Object) promiseVectorHits() *PromiseVectorHits {
+	return &PromiseVectorHits{
+		Promise{
+			db:  o.db,
+			dir: o.directory,
 		},
 	}
 }
 
 func (o *Object) promiseInt64() *Promise {
 	return &Promise{
-		db: o.db,
+		db:         o.db,
+		dir:        o.directory,
+		idempotent: idempotent{object: o.name},
 	}
 }
 
@@ -230,9 +277,6 @@ func (o *Object) Write(data interface{}) *PromiseErr {
 		//res := needObject(p.tr, sub)
 		return func() Chain {
 			value, err := needed.fetch()
-			if err == ErrNotFound {
-				return p.fail(ErrNotFound)
-			}
 			if err != nil {
 				return p.fail(err)
 			}
@@ -268,9 +312,6 @@ func (o *Object) Update(data interface{}, callback func() error) *PromiseErr {
 		//res := needObject(p.tr, sub)
 		return func() Chain {
 			value, err := needed.fetch()
-			if err == ErrNotFound {
-				return p.fail(ErrNotFound)
-			}
 			if err != nil {
 				return p.fail(err)
 			}
@@ -310,7 +351,7 @@ func (o *Object) Set(objectPtr interface{}) *PromiseErr {
 			value, err := needed.fetch()
 			addNew := false
 			var oldObject *Struct
-			if err != ErrNotFound {
+			if !errors.Is(err, ErrNotFound) {
 				if err != nil {
 					return p.fail(err)
 				}
@@ -402,7 +443,7 @@ func (o *Object) UpdateField(objOrID interface{}, fieldName string, callback fun
 				return p.fail(err)
 			}
 			if val == nil {
-				return p.fail(ErrNotFound)
+				return p.fail(&NotFoundError{Object: o.name, Primary: o.getPrimaryTuple(objOrID)})
 			}
 			newValue, err := callback(field.ToInterface(val))
 			if err != nil {
@@ -480,16 +521,21 @@ func (o *Object) SetField(objectPtr interface{}, fieldName string) *PromiseErr {
 			}
 			var oldObject *Struct
 			oldObject = structAny(value.Interface())
+			// indexes are written using whole object, passed one could have only primary and the field
+			newValue := reflect.New(o.reflectType)
+			newValue.Elem().Set(reflect.ValueOf(value.Interface()))
+			newObject := structEditable(newValue.Interface())
+			newObject.setField(field, bytesValue)
 
 			p.tr.Set(key, bytesValue)
 
 			for _, index := range o.indexes {
-				for _, indexField := range index.fields {
-					if indexField == field {
-						fmt.Println("set field write index")
-						index.Write(p.tr, primaryTuple, input, oldObject)
-						break
-					}
+				if !index.uses(field) {
+					continue
+				}
+				err = index.Write(p.tr, primaryTuple, newObject, oldObject)
+				if err != nil {
+					return p.fail(err)
 				}
 			}
 			return p.ok()
@@ -502,6 +548,24 @@ func (o *Object) SetField(objectPtr interface{}, fieldName string) *PromiseErr {
 func (o *Object) Add(data interface{}) *PromiseErr {
 	input := structEditable(data)
 	p := o.promiseErr()
+	// generated primary is kept within idempotency marker, so retried Add returns same object
+	p.idempotent.result = func() tuple.Tuple {
+		result := tuple.Tuple{}
+		for _, field := range o.primaryFields {
+			result = append(result, input.GetBytes(field))
+		}
+		return result
+	}
+	p.idempotent.restore = func(result tuple.Tuple) {
+		if len(result) != len(o.primaryFields) {
+			return
+		}
+		for k, field := range o.primaryFields {
+			if bytes, ok := result[k].([]byte); ok {
+				input.setField(field, bytes)
+			}
+		}
+	}
 	p.do(func() Chain {
 		for _, field := range o.fields {
 			if field.AutoIncrement {
@@ -524,7 +588,7 @@ func (o *Object) Add(data interface{}) *PromiseErr {
 				return p.fail(err)
 			}
 			if sub.Contains(firstKey) {
-				return p.fail(ErrAlreadyExist)
+				return p.fail(&DuplicateError{Object: o.name, Primary: primaryTuple})
 			}
 
 			err = o.doWrite(p.tr, sub, primaryTuple, input, nil, true)
@@ -568,6 +632,27 @@ func (o *Object) Delete(objOrID interface{}) *PromiseErr {
 
 			// remove indexes
 			for _, index := range o.indexes {
+				if index.search {
+					err = index.deleteSearch(p.tr, primaryTuple, object)
+					if err != nil {
+						return p.fail(err)
+					}
+					continue
+				}
+				if index.vectorDims != 0 {
+					err = index.deleteVector(p.tr, primaryTuple)
+					if err != nil {
+						return p.fail(err)
+					}
+					continue
+				}
+				if index.versionstamp {
+					err = index.deleteVersionstamp(p.tr, primaryTuple)
+					if err != nil {
+						return p.fail(err)
+					}
+					continue
+				}
 				toDelete := index.getKey(object)
 				index.Delete(p.tr, primaryTuple, toDelete)
 			}
@@ -609,7 +694,7 @@ func (o *Object) GetBy(objectPtr interface{}, indexKeys ...string) *PromiseErr {
 				return p.fail(err)
 			}
 			if len(rows) == 0 {
-				return p.fail(ErrNotFound)
+				return p.fail(&NotFoundError{Object: o.name})
 			}
 			value := Value{
 				object: o,
@@ -622,6 +707,26 @@ func (o *Object) GetBy(objectPtr interface{}, indexKeys ...string) *PromiseErr {
 	return p
 }
 
+// GetFields fetch only passed fields of the object using primary id, other fields of passed object
+// will stay untouched
+func (o *Object) GetFields(objectPtr interface{}, fieldNames ...string) *PromiseErr {
+	input := structEditable(objectPtr)
+	fields := o.projection(fieldNames)
+	p := o.promiseErr()
+	p.doRead(func() Chain {
+		needed := o.needFields(p.readTr, input.getSubspace(o), fields)
+		return func() Chain {
+			res, err := needed.fetch()
+			if err != nil {
+				return p.fail(err)
+			}
+			input.Fill(o, res)
+			return p.done(nil)
+		}
+	})
+	return p
+}
+
 // MultiGet fetch list of objects using primary id
 func (o *Object) MultiGet(sliceObjectPtr interface{}) *PromiseErr {
 	inputs := o.editSlice(sliceObjectPtr)
@@ -779,7 +884,11 @@ func (o *Object) sliceToKeys(data interface{}) []subspace.Subspace {
 
 // Clear clears all info in object storage
 func (o *Object) Clear() error {
-	_, err := o.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
+	err := o.clearShared()
+	if err != nil {
+		return err
+	}
+	_, err = o.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		start, end := o.dir.FDBRangeKeys()
 		tr.ClearRange(fdb.KeyRange{Begin: start, End: end})
 		start, end = o.miscDir.FDBRangeKeys()
@@ -799,8 +908,7 @@ func (o *Object) Clear() error {
 			tr.ClearRange(fdb.KeyRange{Begin: start, End: end})
 		}
 		for _, index := range o.indexes {
-			start, end = index.dir.FDBRangeKeys()
-			tr.ClearRange(fdb.KeyRange{Begin: start, End: end})
+			index.doClearAll(tr)
 		}
 		for _, counter := range o.counters {
 			start, end = counter.dir.FDBRangeKeys()
@@ -813,7 +921,11 @@ func (o *Object) Clear() error {
 
 // ClearAllIndexes clears all indexes data
 func (o *Object) ClearAllIndexes() error {
-	_, err := o.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
+	err := o.clearShared()
+	if err != nil {
+		return err
+	}
+	_, err = o.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		for _, index := range o.indexes {
 			index.doClearAll(tr)
 		}
@@ -822,6 +934,17 @@ func (o *Object) ClearAllIndexes() error {
 	return err
 }
 
+// clearShared will remove own data of the object from indexes shared with other objects
+func (o *Object) clearShared() error {
+	for _, index := range o.indexes {
+		err := index.clearShared()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (o *Object) sub(key tuple.Tuple) subspace.Subspace {
 	return o.primary.Sub(key...)
 }
@@ -835,6 +958,43 @@ func (o *Object) need(tr fdb.ReadTransaction, sub subspace.Subspace) *needObject
 	return &needed
 }
 
+// needFields will fetch only passed fields of the object, nil fields means whole object
+func (o *Object) needFields(tr fdb.ReadTransaction, sub subspace.Subspace, fields []*Field) *needObject {
+	needed := needObject{
+		object:   o,
+		subspace: sub,
+		fields:   fields,
+	}
+	needed.need(tr, sub)
+	return &needed
+}
+
+// storedFields return names of all the fields stored inside the object
+func (o *Object) storedFields() []string {
+	names := []string{}
+	for _, field := range o.fields {
+		if field.UnStored || field.primary {
+			continue
+		}
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+// projection return list of stored fields to fetch by their names, primary fields are skipped
+// since they are part of the key
+func (o *Object) projection(fieldNames []string) []*Field {
+	fields := []*Field{}
+	for _, fieldName := range fieldNames {
+		field := o.field(fieldName)
+		if field.primary || field.UnStored {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
 // List queries list of items using primary key subspace. Pass no params if fetching all objects
 func (o *Object) List(primary ...interface{}) *Query {
 	query := Query{object: o}
@@ -853,6 +1013,16 @@ func (o *Object) Use(indexFieldNames ...string) *Query {
 	return query.Use(indexFieldNames...)
 }
 
+// Index return index by name or names of the index fields, panic if index is undefined
+func (o *Object) Index(indexKeys ...string) *Index {
+	indexKey := strings.Join(indexKeys, ",")
+	index, ok := o.indexes[indexKey]
+	if !ok {
+		o.panic("index «" + indexKey + "» is undefined")
+	}
+	return index
+}
+
 // Reindex will go around all data and delete add every row
 func (o *Object) Reindex() {
 	query := o.ListAll().Limit(100)
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/directory"
 )
 
 // ObjectBuilder is main interface to declare objects
@@ -62,6 +63,12 @@ func (ob *ObjectBuilder) buildScheme(schemeObj interface{}) {
 			if tag.mutable {
 				field.mutable = true
 			}
+			if tag.descending {
+				field.descending = true
+			}
+			if tag.collation != CollationBinary {
+				field.SetCollation(tag.collation)
+			}
 			if tag.UnStored {
 				field.UnStored = true
 			} else {
@@ -98,9 +105,26 @@ func (ob *ObjectBuilder) addIndex(indexKey string) *Index {
 	go func() {
 		ob.waitInit.Wait()
 		// at this point in time all index properties are probably set up and configured
-		indexSubspace, err := o.dir.CreateOrOpen(o.db, []string{indexKey}, nil)
-		if err != nil {
-			panic(err)
+		var indexSubspace directory.DirectorySubspace
+		var err error
+		if index.global != nil { // search index is shared with other objects
+			index.dir = index.global.index.dir
+			index.statsDir = index.global.index.statsDir
+			index.trigramDir = index.global.index.trigramDir
+			index.global.attach(&index)
+			ob.mux.Lock()
+			o.indexes[indexKey] = &index
+			ob.mux.Unlock()
+			ob.waitAll.Done()
+			return
+		}
+		if index.namespace != nil { // keys are shared with other objects
+			indexSubspace = index.namespace.dir
+		} else {
+			indexSubspace, err = o.dir.CreateOrOpen(o.db, []string{indexKey}, nil)
+			if err != nil {
+				panic(err)
+			}
 		}
 		index.dir = indexSubspace
 		if index.needValueStore() {
@@ -110,6 +134,34 @@ func (ob *ObjectBuilder) addIndex(indexKey string) *Index {
 			}
 			index.valueDir = indexSubspace
 		}
+		if index.search {
+			indexSubspace, err = o.dir.CreateOrOpen(o.db, []string{indexKey, "stats"}, nil)
+			if err != nil {
+				panic(err)
+			}
+			index.statsDir = indexSubspace
+		}
+		if index.fuzzy {
+			indexSubspace, err = o.dir.CreateOrOpen(o.db, []string{indexKey, "trigram"}, nil)
+			if err != nil {
+				panic(err)
+			}
+			index.trigramDir = indexSubspace
+		}
+		if index.vectorDims != 0 {
+			indexSubspace, err = o.dir.CreateOrOpen(o.db, []string{indexKey, "centroid"}, nil)
+			if err != nil {
+				panic(err)
+			}
+			index.centroidDir = indexSubspace
+		}
+		if index.counted {
+			indexSubspace, err = o.dir.CreateOrOpen(o.db, []string{indexKey, "count"}, nil)
+			if err != nil {
+				panic(err)
+			}
+			index.countDir = indexSubspace
+		}
 		ob.mux.Lock()
 		o.indexes[indexKey] = &index
 		ob.mux.Unlock()
@@ -278,6 +330,36 @@ func (ob *ObjectBuilder) AutoIncrement(name string) *ObjectBuilder {
 	return ob
 }
 
+// Descending makes index keys of passed fields stored in descending order, so newest-first scans of
+// the indexes containing those fields will be forward scans
+func (ob *ObjectBuilder) Descending(names ...string) *ObjectBuilder {
+	ob.mux.Lock()
+	for _, name := range names {
+		field, ok := ob.object.fields[name]
+		if !ok {
+			ob.panic("has no key «" + name + "» could not set descending")
+		}
+		field.descending = true
+	}
+	ob.mux.Unlock()
+	return ob
+}
+
+// Collate sets collation for string fields, indexes and GetBy will compare values using it.
+// Use CollationCaseInsensitive to make «John@x.com» and «john@x.com» collide in unique index
+func (ob *ObjectBuilder) Collate(collation Collation, names ...string) *ObjectBuilder {
+	ob.mux.Lock()
+	for _, name := range names {
+		field, ok := ob.object.fields[name]
+		if !ok {
+			ob.panic("has no key «" + name + "» could not set collation")
+		}
+		field.SetCollation(collation)
+	}
+	ob.mux.Unlock()
+	return ob
+}
+
 // Unique index: if object with same field value already presented, Set and Add will return an ErrAlreadyExist
 func (ob *ObjectBuilder) Unique(names ...string) *ObjectBuilder {
 	index := ob.addFieldIndex(names)
@@ -294,6 +376,15 @@ func (ob *ObjectBuilder) UniqueOptional(names ...string) *ObjectBuilder {
 	return ob
 }
 
+// UniqueIn index: value of the fields should be unique across all the objects attached to the namespace.
+// Set and Add will return an *UniqueViolation (which is ErrAlreadyExist) telling which object owns the value
+func (ob *ObjectBuilder) UniqueIn(namespace *UniqueNamespace, names ...string) *ObjectBuilder {
+	index := ob.addFieldIndex(names)
+	index.Unique = true
+	index.namespace = namespace
+	return ob
+}
+
 // Index add an simple index for specific key or set of keys
 func (ob *ObjectBuilder) Index(names ...string) *ObjectBuilder {
 	ob.addFieldIndex(names)
@@ -307,18 +398,43 @@ func (ob *ObjectBuilder) IndexOptional(names ...string) *ObjectBuilder {
 	return ob
 }
 
-// FastIndex will set index storing copy of object, performing denormalisation
+// IndexCounted is the simple index which also maintains number of objects for each prefix of the index key,
+// so Count and Distinct could be answered without scanning the index
+func (ob *ObjectBuilder) IndexCounted(names ...string) *ObjectBuilder {
+	index := ob.addFieldIndex(names)
+	index.counted = true
+	return ob
+}
+
+// IndexCovering add an simple index for the keys which also stores copy of covered fields alongside
+// the index key, so queries using this index and fetching only covered fields will not read objects
+func (ob *ObjectBuilder) IndexCovering(names []string, covered ...string) *ObjectBuilder {
+	index := ob.addFieldIndex(names)
+	index.covered = ob.fieldsList(covered)
+	return ob
+}
+
+// FastIndex will set index storing copy of object, performing denormalisation. Index is not filled for
+// existing objects, Index(names...).Reindex() should be called once after it was added
 func (ob *ObjectBuilder) FastIndex(names ...string) *ObjectBuilder {
+	index := ob.addFieldIndex(names)
+	index.covered = ob.fieldsList(ob.object.storedFields())
+	return ob
+}
+
+// fieldsList return fields by their names, panic if any of the fields is undefined
+func (ob *ObjectBuilder) fieldsList(names []string) []*Field {
+	fields := []*Field{}
 	ob.mux.Lock()
 	for _, name := range names {
-		_, ok := ob.object.fields[name]
+		field, ok := ob.object.fields[name]
 		if !ok {
-			ob.panic("has no key «" + name + "» could not set primar")
+			ob.panic("has no key «" + name + "» could not set index field")
 		}
+		fields = append(fields, field)
 	}
 	ob.mux.Unlock()
-	// init fast index here
-	return ob
+	return fields
 }
 
 // IndexGeo will add and geohash based index to allow geographicly search objects
@@ -333,6 +449,17 @@ func (ob *ObjectBuilder) IndexGeo(latKey string, longKey string, geoPrecision in
 	return &IndexGeo{index: index}
 }
 
+// IndexVersionstamp will add an index ordering objects by commit of the transaction added them,
+// prefix fields (if any) partition the index, so Use(name).List(prefix).From(cursor) will return
+// objects of the partition in commit order. Updates keep the object position
+func (ob *ObjectBuilder) IndexVersionstamp(name string, prefixFields ...string) *IndexVersionstamp {
+	fields := ob.fieldsList(prefixFields)
+	index := ob.addIndex(name)
+	index.fields = fields
+	index.versionstamp = true
+	return &IndexVersionstamp{index: index}
+}
+
 // IndexCustom add an custom index generated dynamicly using callback function
 // custom indexes in an general way to implement any index on top of it
 func (ob *ObjectBuilder) IndexCustom(key string, cb func(object interface{}) KeyTuple) *Index {
@@ -355,6 +482,44 @@ func (ob *ObjectBuilder) IndexSearch(key string, options ...IndexOption) *IndexS
 	return &IndexSearch{index: index}
 }
 
+// IndexVector will add similarity index of the embedding field ([]float32), vectors are split into lists
+// by nearest centroid, so IndexVector.Nearest reads only lists nearest to the query
+func (ob *ObjectBuilder) IndexVector(key string, dims int, metric VectorMetric, options ...IndexOption) *IndexVector {
+	index := ob.addFieldIndex([]string{key})
+	field := ob.object.fields[key]
+	if field.Kind != reflect.Slice || (field.SubKind != reflect.Float32 && field.SubKind != reflect.Float64) {
+		ob.panic("field " + key + " should be []float32 for IndexVector")
+	}
+	if dims < 1 {
+		ob.panic("vector index «" + key + "» should have dimensions")
+	}
+	for _, opt := range options {
+		index.SetOption(opt)
+	}
+	index.vectorDims = dims
+	index.vectorLists = vectorDefaultLists
+	index.vectorMetric = metric
+	index.vectorCache = &vectorCache{}
+	return &IndexVector{index: index}
+}
+
+// SearchIn will add string fields of the object to the search index shared between objects
+func (ob *ObjectBuilder) SearchIn(search *SearchIndex, names ...string) *ObjectBuilder {
+	fields := ob.fieldsList(names)
+	for _, field := range fields {
+		if field.Kind != reflect.String {
+			ob.panic("field " + field.Name + " should be string for SearchIn")
+		}
+	}
+	index := ob.addIndex("search:" + search.name)
+	index.fields = fields
+	index.search = true
+	index.global = search
+	index.analyzer = search.index.analyzer
+	index.fuzzy = search.index.fuzzy
+	return ob
+}
+
 // Counter will count all objects with same value of passed fields
 func (ob *ObjectBuilder) Counter(fieldNames ...string) *Counter {
 	fields := []*Field{}
@@ -1,9 +1,11 @@
 package stored
 
 import (
+	"context"
 	"errors"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 )
 
 // Chain is the recursive functions chain
@@ -11,15 +13,22 @@ type Chain func() Chain
 
 // Promise is an basic promise object
 type Promise struct {
-	db        *fdb.Database
-	readTr    fdb.ReadTransaction
-	tr        fdb.Transaction
-	chain     Chain
-	after     func() PromiseAny
-	err       error
-	readOnly  bool
-	resp      interface{}
-	confirmed bool
+	db          *fdb.Database
+	readTr      fdb.ReadTransaction
+	tr          fdb.Transaction
+	chain       Chain
+	after       func() PromiseAny
+	err         error
+	readOnly    bool
+	resp        interface{}
+	confirmed   bool
+	ctx         context.Context // transaction of the promise is bound to the context
+	dir         *Directory      // directory default transaction options are taken from
+	options     *TxOptions
+	transaction *Transaction // transaction the promise is attached to using Do
+	onCommit    []func()
+	onFailure   []func(err error)
+	idempotent  idempotent // write is performed once for the idempotency key
 }
 
 // PromiseAny describes any type of promise
@@ -61,8 +70,16 @@ func (p *Promise) getValueField(o *Object, field *Field, bytes []byte) *Value {
 	return &val
 }
 
+// getChain return chain of the promise, checking idempotency key if set
+func (p *Promise) getChain() Chain {
+	if p.idempotent.key != "" && p.chain != nil {
+		return p.idempotentChain(p.chain)
+	}
+	return p.chain
+}
+
 func (p *Promise) execute() (interface{}, error) {
-	next := p.chain()
+	next := p.getChain()()
 	for next != nil {
 		next = next()
 	}
@@ -82,37 +99,129 @@ func (p *Promise) clear() {
 
 func (p *Promise) transact() (resp interface{}, err error) {
 	if p.readTr != nil {
+		p.clear()
+		if p.ctx != nil && p.ctx.Err() != nil {
+			p.err = p.ctx.Err()
+			return nil, p.err
+		}
+		resp, err = p.execute()
+		p.confirmed = true
+		if p.transaction != nil { // hooks will be called once the transaction is finished
+			p.transaction.attachHooks(p, err)
+		}
+		return
+	}
+	defer func() {
+		p.finished(err)
+	}()
+	if p.db == nil { // promise has nothing to read, like All of no promises
 		p.clear()
 		resp, err = p.execute()
 		p.confirmed = true
 		return
 	}
 	if p.readOnly {
-		resp, err = p.db.ReadTransact(func(tr fdb.ReadTransaction) (interface{}, error) {
+		resp, err = dbReadTransact(p.ctx, p.txOptions(), p.db, func(tr fdb.ReadTransaction) (interface{}, error) {
 			p.clear() // since transaction could be repeated - should clear everything
 			p.readTr = tr.Snapshot()
 			return p.execute()
 		})
 		p.confirmed = true
+		p.ctxErr(err)
 		return
 
 	}
-	resp, err = p.db.Transact(func(tr fdb.Transaction) (ret interface{}, err error) {
+	options := p.txOptions()
+	resp, err = dbTransact(p.ctx, options, p.db, func(tr fdb.Transaction) (ret interface{}, err error) {
 		p.clear() // clear tmp data in case if transaction resended
 		p.tr = tr
 		p.readTr = tr
+		if options != nil && options.Snapshot {
+			p.readTr = tr.Snapshot()
+		}
 		return p.execute()
 	})
 	p.confirmed = true
+	p.ctxErr(err)
 	return
 }
 
+// txOptions return options of the promise, or default options of the directory
+func (p *Promise) txOptions() *TxOptions {
+	if p.options != nil {
+		return p.options
+	}
+	if p.dir != nil {
+		return p.dir.txOptions
+	}
+	return nil
+}
+
+// ctxErr will keep error of the transaction bound to the context or options, so cancellation
+// and exhausted retries are returned instead of errors of cancelled reads
+func (p *Promise) ctxErr(err error) {
+	if (p.ctx != nil || p.txOptions() != nil) && err != nil {
+		p.err = err
+	}
+}
+
 // Err will execute the promise and return error
 func (p *Promise) Err() error {
 	_, err := p.transact()
 	return err
 }
 
+// ErrCtx will execute the promise bound to the context and return error, deadline of the context
+// becomes timeout of the transaction and ctx.Err() is returned once context is done
+func (p *Promise) ErrCtx(ctx context.Context) error {
+	p.ctx = ctx
+	_, err := p.transact()
+	return err
+}
+
+// Options sets how transaction of the promise is performed and retried, overrides options of the Directory
+func (p *Promise) Options(options TxOptions) *Promise {
+	p.options = &options
+	return p
+}
+
+// Idempotent sets idempotency key of the write, once the promise is committed repeated writes with
+// the same key are skipped until marker of the key expires. Marker is written in the same transaction,
+// so retries after commit_unknown_result will not write data twice
+func (p *Promise) Idempotent(key string) *Promise {
+	if p.readOnly {
+		panic("idempotency key could be set only for write promises")
+	}
+	p.idempotent.key = key
+	return p
+}
+
+// OnCommit will register function called once transaction of the promise is committed, unlike code
+// inside transaction callback it is called only once
+func (p *Promise) OnCommit(fn func()) *Promise {
+	p.onCommit = append(p.onCommit, fn)
+	return p
+}
+
+// OnFailure will register function called once the promise finally failed, after all the retries
+func (p *Promise) OnFailure(fn func(err error)) *Promise {
+	p.onFailure = append(p.onFailure, fn)
+	return p
+}
+
+// finished will call hooks of the promise depending on result of the transaction
+func (p *Promise) finished(err error) {
+	if err == nil {
+		for _, fn := range p.onCommit {
+			fn()
+		}
+		return
+	}
+	for _, fn := range p.onFailure {
+		fn(err)
+	}
+}
+
 // Bool return bool value if promise contins true or false
 func (p *Promise) Bool() (bool, error) {
 	data, err := p.transact()
@@ -147,6 +256,23 @@ func (p *Promise) Int64() (int64, error) {
 	return res, nil
 }
 
+// Versionstamp return versionstamp if promise contain one, could be used as cursor
+func (p *Promise) Versionstamp() (tuple.Versionstamp, error) {
+	data, err := p.transact()
+	var res tuple.Versionstamp
+	if err != nil {
+		return res, err
+	}
+	if data == nil {
+		panic("promise does not contain any value, use Scan")
+	}
+	res, ok := data.(tuple.Versionstamp)
+	if !ok {
+		return res, errors.New("promise value is not versionstamp")
+	}
+	return res, nil
+}
+
 // After will perform an additional promise right after current one will be finised
 // This works in transactions as well as in standalone promises, child promise will
 // be executed in same transaction as parent
@@ -192,6 +318,7 @@ func (p *Promise) Do(t *Transaction) *Promise {
 	}
 	p.tr = t.tr
 	p.readTr = t.readTr
+	p.transaction = t
 	return p
 }
 
@@ -13,6 +13,7 @@ func (p *PromiseErr) Do(t *Transaction) *PromiseErr {
 	}
 	p.tr = t.tr
 	p.readTr = t.readTr
+	p.transaction = t
 	return p
 }
 
@@ -24,3 +25,9 @@ func (p *PromiseErr) Err() error {
 	}
 	return nil
 }
+
+// Idempotent sets idempotency key of the write, see Promise.Idempotent
+func (p *PromiseErr) Idempotent(key string) *PromiseErr {
+	p.Promise.Idempotent(key)
+	return p
+}
@@ -0,0 +1,39 @@
+package stored
+
+import "errors"
+
+// Facet is distinct value of an index with number of objects having this value
+type Facet struct {
+	Value interface{}
+	Count int64
+}
+
+// PromiseFacets is implements everything promise implements but also list of facets
+type PromiseFacets struct {
+	Promise
+}
+
+// Do will attach promise to transaction, so promise will be called within passed transaction
+// Promise should be inside an transaction callback, because transaction could be resent
+func (p *PromiseFacets) Do(t *Transaction) *PromiseFacets {
+	if !t.started {
+		panic("transaction not started, could not use in Promise")
+	}
+	p.tr = t.tr
+	p.readTr = t.readTr
+	p.transaction = t
+	return p
+}
+
+// Facets will return list of distinct values with counts
+func (p *PromiseFacets) Facets() ([]Facet, error) {
+	data, err := p.transact()
+	if err != nil {
+		return nil, err
+	}
+	res, ok := data.([]Facet)
+	if !ok {
+		return nil, errors.New("promise value is not facets")
+	}
+	return res, nil
+}
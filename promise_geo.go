@@ -0,0 +1,33 @@
+package stored
+
+import "errors"
+
+// PromiseGeoHits is implements everything promise implements but also list of geo hits
+type PromiseGeoHits struct {
+	Promise
+}
+
+// Do will attach promise to transaction, so promise will be called within passed transaction
+// Promise should be inside an transaction callback, because transaction could be resent
+func (p *PromiseGeoHits) Do(t *Transaction) *PromiseGeoHits {
+	if !t.started {
+		panic("transaction not started, could not use in Promise")
+	}
+	p.tr = t.tr
+	p.readTr = t.readTr
+	p.transaction = t
+	return p
+}
+
+// Hits will return found objects ordered by distance
+func (p *PromiseGeoHits) Hits() ([]GeoHit, error) {
+	data, err := p.transact()
+	if err != nil {
+		return nil, err
+	}
+	res, ok := data.([]GeoHit)
+	if !ok {
+		return nil, errors.New("promise value is not geo hits")
+	}
+	return res, nil
+}
@@ -0,0 +1,33 @@
+package stored
+
+import "errors"
+
+// PromiseHits is implements everything promise implements but also list of search hits
+type PromiseHits struct {
+	Promise
+}
+
+// Do will attach promise to transaction, so promise will be called within passed transaction
+// Promise should be inside an transaction callback, because transaction could be resent
+func (p *PromiseHits) Do(t *Transaction) *PromiseHits {
+	if !t.started {
+		panic("transaction not started, could not use in Promise")
+	}
+	p.tr = t.tr
+	p.readTr = t.readTr
+	p.transaction = t
+	return p
+}
+
+// Hits will return found objects ordered by relevance
+func (p *PromiseHits) Hits() ([]SearchHit, error) {
+	data, err := p.transact()
+	if err != nil {
+		return nil, err
+	}
+	res, ok := data.([]SearchHit)
+	if !ok {
+		return nil, errors.New("promise value is not search hits")
+	}
+	return res, nil
+}
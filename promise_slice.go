@@ -1,5 +1,7 @@
 package stored
 
+import "context"
+
 // PromiseSlice is implements everything promise implements but more
 type PromiseSlice struct {
 	Promise
@@ -53,6 +55,24 @@ func (p *PromiseSlice) ScanAll(slicePointer interface{}) error {
 	return slice.ScanAll(slicePointer)
 }
 
+// ScanAllCtx values inside promise, transaction is bound to the context
+func (p *PromiseSlice) ScanAllCtx(ctx context.Context, slicePointer interface{}) error {
+	p.ctx = ctx
+	return p.ScanAll(slicePointer)
+}
+
+// Options sets how transaction of the promise is performed and retried, overrides options of the Directory
+func (p *PromiseSlice) Options(options TxOptions) *PromiseSlice {
+	p.options = &options
+	return p
+}
+
+// Idempotent sets idempotency key of the write, see Promise.Idempotent
+func (p *PromiseSlice) Idempotent(key string) *PromiseSlice {
+	p.Promise.Idempotent(key)
+	return p
+}
+
 // Slice will return slice pointer
 func (p *PromiseSlice) Slice() *Slice {
 	if !p.confirmed {
@@ -73,6 +93,7 @@ func (p *PromiseSlice) Do(t *Transaction) *PromiseSlice {
 	}
 	p.tr = t.tr
 	p.readTr = t.readTr
+	p.transaction = t
 	return p
 }
 
@@ -85,6 +106,33 @@ func (p *PromiseSlice) done(resp interface{}) Chain {
 	return r
 }
 
+// doneHits will finish the promise with slice of search hits values
+func (p *PromiseSlice) doneHits(hits []SearchHit) Chain {
+	slice := Slice{}
+	for _, hit := range hits {
+		slice.Append(hit.Value)
+	}
+	return p.done(&slice)
+}
+
+// doneGeoHits will finish the promise with slice of geo hits values
+func (p *PromiseSlice) doneGeoHits(hits []GeoHit) Chain {
+	slice := Slice{}
+	for _, hit := range hits {
+		slice.Append(hit.Value)
+	}
+	return p.done(&slice)
+}
+
+// doneVectorHits will finish the promise with slice of vector hits values
+func (p *PromiseSlice) doneVectorHits(hits []VectorHit) Chain {
+	slice := Slice{}
+	for _, hit := range hits {
+		slice.Append(hit.Value)
+	}
+	return p.done(&slice)
+}
+
 // Limit is meant to set limit of the query this
 func (p *PromiseSlice) Limit(limit int) *PromiseSlice {
 	p.limit = limit
@@ -1,5 +1,7 @@
 package stored
 
+import "context"
+
 // PromiseValue is implements everything promise implements but also values
 type PromiseValue struct {
 	Promise
@@ -13,6 +15,7 @@ func (p *PromiseValue) Do(t *Transaction) *PromiseValue {
 	}
 	p.tr = t.tr
 	p.readTr = t.readTr
+	p.transaction = t
 	return p
 }
 
@@ -28,3 +31,21 @@ func (p *PromiseValue) Scan(obj interface{}) error {
 	}
 	return value.Scan(obj)
 }
+
+// ScanCtx appened passed object with fetched fields, transaction is bound to the context
+func (p *PromiseValue) ScanCtx(ctx context.Context, obj interface{}) error {
+	p.ctx = ctx
+	return p.Scan(obj)
+}
+
+// Options sets how transaction of the promise is performed and retried, overrides options of the Directory
+func (p *PromiseValue) Options(options TxOptions) *PromiseValue {
+	p.options = &options
+	return p
+}
+
+// Idempotent sets idempotency key of the write, see Promise.Idempotent
+func (p *PromiseValue) Idempotent(key string) *PromiseValue {
+	p.Promise.Idempotent(key)
+	return p
+}
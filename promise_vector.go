@@ -0,0 +1,33 @@
+package stored
+
+import "errors"
+
+// PromiseVectorHits is implements everything promise implements but also list of vector hits
+type PromiseVectorHits struct {
+	Promise
+}
+
+// Do will attach promise to transaction, so promise will be called within passed transaction
+// Promise should be inside an transaction callback, because transaction could be resent
+func (p *PromiseVectorHits) Do(t *Transaction) *PromiseVectorHits {
+	if !t.started {
+		panic("transaction not started, could not use in Promise")
+	}
+	p.tr = t.tr
+	p.readTr = t.readTr
+	p.transaction = t
+	return p
+}
+
+// Hits will return found objects most similar first
+func (p *PromiseVectorHits) Hits() ([]VectorHit, error) {
+	data, err := p.transact()
+	if err != nil {
+		return nil, err
+	}
+	res, ok := data.([]VectorHit)
+	if !ok {
+		return nil, errors.New("promise value is not vector hits")
+	}
+	return res, nil
+}
@@ -1,7 +1,7 @@
 package stored
 
 import (
-	"fmt"
+	"context"
 	"reflect"
 	"strconv"
 	"strings"
@@ -11,6 +11,8 @@ import (
 	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 )
 
+const queryPageKeys = 1000 // number of keys read at once by projected scan without limit and by Count
+
 // Query is interface for query building
 type Query struct {
 	object  *Object
@@ -25,6 +27,7 @@ type Query struct {
 	limit       int
 	reverse     bool
 	onlyPrimary bool
+	projection  []*Field // if set only this fields will be fetched
 	fn          func()
 }
 
@@ -61,6 +64,13 @@ func (q *Query) OnlyPrimary() *Query {
 	return q
 }
 
+// Fields allow you to fetch only passed fields of the objects, other fields of scanned structs will
+// stay empty. If query uses index covering all the fields, objects will be fetched from the index only
+func (q *Query) Fields(fieldNames ...string) *Query {
+	q.projection = q.object.projection(fieldNames)
+	return q
+}
+
 // SetReverse set reverse value from param
 func (q *Query) SetReverse(reverse bool) *Query {
 	q.reverse = reverse
@@ -146,6 +156,11 @@ func (q *Query) ScanAll(slicePointer interface{}) error {
 	return q.execute().ScanAll(slicePointer)
 }
 
+// ScanAllCtx scans the query result into the passed, transaction is bound to the context
+func (q *Query) ScanAllCtx(ctx context.Context, slicePointer interface{}) error {
+	return q.execute().ScanAllCtx(ctx, slicePointer)
+}
+
 // TryAll scans the query result within the transaction
 func (q *Query) TryAll(tr *Transaction, slicePointer interface{}) {
 	q.execute().TryAll(tr, slicePointer)
@@ -158,6 +173,91 @@ func (q *Query) Slice() *Slice {
 	//return sliceI.(*Slice)
 }
 
+// Count will return number of objects selected by the query without fetching them.
+// Query limit is ignored. If index is counted and no From or To is set, the count is read from
+// the maintained counter. Without index the primary range is read page by page in separate transactions
+func (q *Query) Count() *Promise {
+	p := q.object.promiseInt64()
+	p.doRead(func() Chain {
+		if q.index != nil {
+			count, err := q.index.getCount(p.readTr, q)
+			if err != nil {
+				return p.fail(err)
+			}
+			return p.done(count)
+		}
+		count, err := q.countPaged()
+		if err != nil {
+			return p.fail(err)
+		}
+		return p.done(count)
+	})
+	return p
+}
+
+// countPaged will count objects of the primary range page by page, each page is read by its own transaction
+// and the next one starts after the field keys of the last object counted
+func (q *Query) countPaged() (int64, error) {
+	keyLen := len(q.object.primaryFields)
+	_, r := q.getPrimaryRange()
+	begin, end := r.Begin.FDBKey(), r.End.FDBKey()
+	count := int64(0)
+	for {
+		res, err := q.object.db.ReadTransact(func(tr fdb.ReadTransaction) (interface{}, error) {
+			return tr.GetRange(fdb.KeyRange{Begin: begin, End: end}, fdb.RangeOptions{Limit: queryPageKeys}).GetSliceWithError()
+		})
+		if err != nil {
+			return 0, err
+		}
+		rows := res.([]fdb.KeyValue)
+		var lastTuple tuple.Tuple
+		for _, kv := range rows {
+			fullTuple, err := q.object.primary.Unpack(kv.Key)
+			if err != nil {
+				return 0, err
+			}
+			if len(fullTuple) < keyLen {
+				return 0, &CorruptionError{Key: kv.Key, Reason: "primary key too short"}
+			}
+			primaryTuple := fullTuple[:keyLen]
+			if lastTuple == nil || !reflect.DeepEqual(primaryTuple, lastTuple) {
+				count++
+			}
+			lastTuple = primaryTuple
+		}
+		if len(rows) < queryPageKeys {
+			return count, nil
+		}
+		_, objectEnd := q.object.sub(lastTuple).FDBRangeKeys() // skip the rest of the object
+		begin = objectEnd.FDBKey()
+	}
+}
+
+// getPrimaryRange return key range of primary subspace selected by query
+func (q *Query) getPrimaryRange() (subspace.Subspace, fdb.KeyRange) {
+	var sub subspace.Subspace
+	sub = q.object.primary
+	if q.primary != nil {
+		sub = sub.Sub(q.primary...)
+	}
+	start, end := sub.FDBRangeKeys()
+	if q.from != nil {
+		if q.reverse {
+			end = sub.Pack(q.from)
+		} else {
+			start = sub.Pack(q.from)
+		}
+	}
+	if q.to != nil {
+		if q.reverse {
+			start = sub.Pack(q.to)
+		} else {
+			end = sub.Pack(q.to)
+		}
+	}
+	return sub, fdb.KeyRange{Begin: start, End: end}
+}
+
 // execute the query
 // could be called several times with one query
 func (q *Query) execute() *PromiseSlice {
@@ -172,6 +272,17 @@ func (q *Query) execute() *PromiseSlice {
 				}
 				return p.done(slice)
 			}
+			fields := q.projection
+			if fields == nil {
+				fields = q.object.projection(q.object.storedFields())
+			}
+			if q.index.covers(fields) {
+				slice, err := q.index.getCoveredList(p.readTr, q, fields)
+				if err != nil {
+					return p.fail(err)
+				}
+				return p.done(slice)
+			}
 			values, err := q.index.getList(p.readTr, q)
 			if err != nil {
 				return p.fail(err)
@@ -187,28 +298,15 @@ func (q *Query) execute() *PromiseSlice {
 			return p.done(&slice)
 		}
 
-		var sub subspace.Subspace
-		sub = q.object.primary
-		if q.primary != nil {
-
-			sub = sub.Sub(q.primary...)
-		}
-		start, end := sub.FDBRangeKeys()
-		if q.from != nil {
-			if q.reverse {
-				end = sub.Pack(q.from)
-			} else {
-				start = sub.Pack(q.from)
-			}
-		}
-		if q.to != nil {
-			if q.reverse {
-				start = sub.Pack(q.to)
-			} else {
-				end = sub.Pack(q.to)
+		if q.projection != nil {
+			slice, err := q.getProjectedList(p.readTr)
+			if err != nil {
+				return p.fail(err)
 			}
+			return p.done(slice)
 		}
-		r := fdb.KeyRange{Begin: start, End: end}
+
+		_, r := q.getPrimaryRange()
 
 		limit := q.object.getKeyLimit(q.limit)
 		if q.next.started {
@@ -244,8 +342,7 @@ func (q *Query) execute() *PromiseSlice {
 			}
 
 			if len(fullTuple) < keyLen {
-				fmt.Println("data corrupt", len(fullTuple), "vs", keyLen)
-				return p.fail(ErrDataCorrupt)
+				return p.fail(&CorruptionError{Key: kv.Key, Reason: "primary key too short"})
 			}
 			primaryTuple := fullTuple[:keyLen]
 
@@ -263,12 +360,12 @@ func (q *Query) execute() *PromiseSlice {
 			}
 			fieldsKey := fullTuple[keyLen:]
 			if len(fieldsKey) > 1 {
-				q.object.panic("nested fields not yet supported")
+				return p.fail(&CorruptionError{Key: kv.Key, Reason: "nested fields not yet supported"})
 			}
 			if len(fieldsKey) == 1 {
 				keyName, ok := fieldsKey[0].(string)
 				if !ok {
-					q.object.panic("invalid key, not string")
+					return p.fail(&CorruptionError{Key: kv.Key, Reason: "field key is not string"})
 				}
 				elem[keyName] = kv.Value
 			}
@@ -297,6 +394,84 @@ func (q *Query) execute() *PromiseSlice {
 	return p
 }
 
+// getProjectedList will scan primary subspace page by page keeping only the projected fields of the objects,
+// pages are limited by the query limit or by queryPageKeys when no limit is set
+func (q *Query) getProjectedList(tr fdb.ReadTransaction) (*Slice, error) {
+	keyLen := len(q.object.primaryFields)
+	projected := map[string]bool{}
+	for _, field := range q.projection {
+		projected[field.Name] = true
+	}
+	_, r := q.getPrimaryRange()
+	begin, end := r.Begin.FDBKey(), r.End.FDBKey()
+	pageKeys := q.object.getKeyLimit(q.limit)
+	if pageKeys == 0 {
+		pageKeys = queryPageKeys
+	}
+	slice := Slice{values: []*Value{}}
+	elem := valueRaw{}
+	var lastTuple tuple.Tuple
+	appendLast := func() {
+		value := Value{object: q.object}
+		value.fromRaw(elem)
+		value.fromKeyTuple(lastTuple)
+		slice.Append(&value)
+		elem = valueRaw{}
+	}
+scan:
+	for {
+		rows, err := tr.GetRange(fdb.KeyRange{Begin: begin, End: end}, fdb.RangeOptions{
+			Limit:   pageKeys,
+			Reverse: q.reverse,
+		}).GetSliceWithError()
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range rows {
+			fullTuple, err := q.object.primary.Unpack(kv.Key)
+			if err != nil {
+				return nil, err
+			}
+			if len(fullTuple) < keyLen {
+				return nil, &CorruptionError{Key: kv.Key, Reason: "primary key too short"}
+			}
+			primaryTuple := fullTuple[:keyLen]
+			if lastTuple != nil && !reflect.DeepEqual(primaryTuple, lastTuple) {
+				appendLast()
+				if q.limit != 0 && slice.Len() == q.limit {
+					break scan
+				}
+			}
+			fieldsKey := fullTuple[keyLen:]
+			if len(fieldsKey) == 1 {
+				keyName, ok := fieldsKey[0].(string)
+				if ok && projected[keyName] {
+					elem[keyName] = kv.Value
+				}
+			}
+			lastTuple = primaryTuple
+		}
+		if len(rows) < pageKeys {
+			if lastTuple != nil {
+				appendLast()
+			}
+			break
+		}
+		lastKey := rows[len(rows)-1].Key
+		if q.reverse {
+			end = lastKey
+		} else {
+			begin = fdb.Key(append(append([]byte{}, lastKey...), 0x00))
+		}
+	}
+	if !reflect.DeepEqual(q.from, lastTuple) {
+		q.next.from = lastTuple
+	} else {
+		q.next.from = nil
+	}
+	return &slice, nil
+}
+
 // Next sets from identifier from nextFrom; return true if more data could be fetched
 func (q *Query) Next() bool {
 	if q.next.started {
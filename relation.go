@@ -80,6 +80,18 @@ func (r *Relation) CounterClient(object *ObjectBuilder, fieldName string) {
 	r.counterClient = field
 }
 
+// notFound return error describing missing relation between objects
+func (r *Relation) notFound(hostPrimary, clientPrimary tuple.Tuple) error {
+	primary := append(append(tuple.Tuple{}, hostPrimary...), clientPrimary...)
+	return &NotFoundError{Object: r.host.name + "/" + r.client.name, Primary: primary}
+}
+
+// duplicate return error describing existing relation between objects
+func (r *Relation) duplicate(hostPrimary, clientPrimary tuple.Tuple) error {
+	primary := append(append(tuple.Tuple{}, hostPrimary...), clientPrimary...)
+	return &DuplicateError{Object: r.host.name + "/" + r.client.name, Primary: primary}
+}
+
 // return primary values, no masser object wass passed or primary value
 func (r *Relation) getPrimary(hostObject interface{}, clientObject interface{}) (tuple.Tuple, tuple.Tuple) {
 	//hostPrimary := r.host.GetPrimaryField().fromAnyInterface(hostObject)
@@ -164,10 +176,10 @@ func (r *Relation) Add(hostOrID interface{}, clientOrID interface{}) *PromiseErr
 	p.do(func() Chain {
 		val, err := p.tr.Get(r.hostDir.Sub(hostPrimary...).Pack(clientPrimary)).Get()
 		if err != nil {
-			p.fail(err)
+			return p.fail(err)
 		}
 		if val != nil { // already exists
-			p.fail(ErrAlreadyExist)
+			return p.fail(r.duplicate(hostPrimary, clientPrimary))
 		}
 		if r.counter { // increment if not exists
 			p.tr.Add(r.infoDir.Sub(keyRelHostCount).Pack(hostPrimary), countInc)
@@ -177,7 +189,7 @@ func (r *Relation) Add(hostOrID interface{}, clientOrID interface{}) *PromiseErr
 		// getting data to store inside relation kv
 		hostVal, clientVal, dataErr := r.getData(hostOrID, clientOrID)
 		if dataErr != nil {
-			p.fail(dataErr)
+			return p.fail(dataErr)
 		}
 
 		p.tr.Set(r.hostDir.Sub(hostPrimary...).Pack(clientPrimary), clientVal)
@@ -252,14 +264,12 @@ func (r *Relation) GetHostsCount(clientOrID interface{}) *Promise {
 		//row, err := r.host.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		//row, err := p.readTr.Get(r.infoDir.Sub(keyRelClientCount).Pack(clientPrimary)).Get()
 		row, err := r.getClientCounter(clientPrimary, p.readTr).Get()
-
-		if row == nil {
-			return p.fail(ErrNotFound)
-			//return nil, ErrNotFound
-		}
 		if err != nil {
 			return p.fail(err)
 		}
+		if row == nil {
+			return p.fail(&NotFoundError{Object: r.client.name, Primary: clientPrimary})
+		}
 		return p.done(ToInt64(row))
 	})
 	return p
@@ -291,13 +301,12 @@ func (r *Relation) GetClientsCount(hostOrID interface{}) *Promise {
 
 		//row, err := r.host.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
 		row, err := p.readTr.Get(r.infoDir.Sub(keyRelHostCount).Pack(hostPrimary)).Get()
-		if row == nil {
-			return p.fail(ErrNotFound)
-			//return nil, ErrNotFound
-		}
 		if err != nil {
 			return p.fail(err)
 		}
+		if row == nil {
+			return p.fail(&NotFoundError{Object: r.host.name, Primary: hostPrimary})
+		}
 		return p.done(ToInt64(row))
 	})
 	return p
@@ -343,9 +352,8 @@ func (r *Relation) getHostsOrClients(objOrID interface{}, from interface{}, host
 				fmt.Printf("Unable to unpack index key: %v\n", err)
 				return p.fail(err)
 			}
-			l := len(keyTuple)
-			if l < 1 {
-				panic("empty key")
+			if len(keyTuple) < 1 {
+				return p.fail(&CorruptionError{Key: kv.Key, Reason: "relation key is empty"})
 			}
 			//obj.need(tr, obj.sub(keyTuple))
 			needed = append(needed, obj.need(p.readTr, obj.sub(keyTuple)))
@@ -444,7 +452,7 @@ func (r *Relation) SetHostData(hostObj interface{}, clientOrID interface{}) *Pro
 			return p.fail(err)
 		}
 		if row == nil {
-			return p.fail(ErrNotFound)
+			return p.fail(r.notFound(hostPrimary, clientPrimary))
 		}
 
 		// getting data to store inside relation kv
@@ -470,8 +478,7 @@ func (r *Relation) SetClientData(hostOrID interface{}, clientObj interface{}) *P
 			return p.fail(err)
 		}
 		if row == nil {
-			fmt.Println("Catched error")
-			return p.fail(ErrNotFound)
+			return p.fail(r.notFound(hostPrimary, clientPrimary))
 		}
 
 		// getting data to store inside relation kv
@@ -511,7 +518,7 @@ func (r *Relation) doUpdateData(hostObj interface{}, clientObj interface{}, call
 					return p.fail(err)
 				}
 				if hostData == nil {
-					return p.fail(ErrNotFound)
+					return p.fail(r.notFound(hostPrimary, clientPrimary))
 				}
 				hostEditable.setField(r.hostDataField, hostData)
 			}
@@ -521,7 +528,7 @@ func (r *Relation) doUpdateData(hostObj interface{}, clientObj interface{}, call
 					return p.fail(err)
 				}
 				if clientData == nil {
-					return p.fail(ErrNotFound)
+					return p.fail(r.notFound(hostPrimary, clientPrimary))
 				}
 				clientEditable.setField(r.clientDataField, clientData)
 			}
@@ -567,7 +574,7 @@ func (r *Relation) SetData(hostObj interface{}, clientObj interface{}) *Promise
 				return p.fail(err)
 			}
 			if row == nil {
-				return p.fail(ErrNotFound)
+				return p.fail(r.notFound(hostPrimary, clientPrimary))
 			}
 
 			// getting data to store inside relation kv
@@ -629,7 +636,7 @@ func (r *Relation) GetClientDataIDs(hostOrID interface{}, clientOrID interface{}
 			return Nan, err
 		}
 		if val == nil { // not exists increment here
-			return Nan, ErrNotFound
+			return Nan, r.notFound(hostPrimary, clientPrimary)
 		}
 		return val, nil
 	})
@@ -649,7 +656,7 @@ func (r *Relation) FillClientData(hostOrID interface{}, client interface{}) *Pro
 			}
 			//fmt.Println("CLIENT DATA", hostPrimary, clientPrimary, "=>", val)
 			if val == nil { // not exists increment here
-				return p.fail(ErrNotFound)
+				return p.fail(r.notFound(hostPrimary, clientPrimary))
 			}
 			return func() Chain {
 				value := p.getValueField(r.client, r.clientDataField, val)
@@ -674,7 +681,7 @@ func (r *Relation) GetClientData(hostOrID interface{}, clientOrID interface{}) *
 			}
 			//fmt.Println("CLIENT DATA", hostPrimary, clientPrimary, "=>", val)
 			if val == nil { // not exists increment here
-				return p.fail(ErrNotFound)
+				return p.fail(r.notFound(hostPrimary, clientPrimary))
 			}
 
 			raw := valueRaw{}
@@ -702,7 +709,7 @@ func (r *Relation) FillHostData(host interface{}, clientOrID interface{}) *Promi
 			return p.fail(err)
 		}
 		if val == nil { // not exists increment here
-			return p.fail(ErrNotFound)
+			return p.fail(r.notFound(hostPrimary, clientPrimary))
 		}
 		return func() Chain {
 			value := p.getValueField(r.host, r.hostDataField, val)
@@ -723,7 +730,7 @@ func (r *Relation) GetHostData(hostOrID interface{}, clientOrID interface{}) *Pr
 			return p.fail(err)
 		}
 		if val == nil { // not exists increment here
-			return p.fail(ErrNotFound)
+			return p.fail(r.notFound(hostPrimary, clientPrimary))
 		}
 		return func() Chain {
 			return p.done(p.getValueField(r.host, r.hostDataField, val))
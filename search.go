@@ -3,6 +3,8 @@ package stored
 import (
 	"regexp"
 	"strings"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 )
 
 var searchWordSplit = regexp.MustCompile("[,. ]+")
@@ -11,11 +13,67 @@ var searchWordSplit = regexp.MustCompile("[,. ]+")
 func searchGetInputWords(index *Index, input *Struct) (words []string) {
 	for _, field := range index.fields {
 		str := input.Get(field).(string)
-		words = append(words, searchSplit(str)...)
+		words = append(words, index.searchAnalyzer().Analyze(str)...)
 	}
 	return
 }
 
+// searchPartition return values of partition fields of the object
+func (i *Index) searchPartition(input *Struct) tuple.Tuple {
+	if i.partition == nil {
+		return nil
+	}
+	partition := tuple.Tuple{}
+	for _, field := range i.partition {
+		partition = append(partition, field.indexElement(input.Get(field)))
+	}
+	return partition
+}
+
+// encodePartition will convert partition values passed by user to the form stored inside the index
+func (i *Index) encodePartition(values []interface{}) tuple.Tuple {
+	partition := tuple.Tuple{}
+	for k, value := range values {
+		if k < len(i.partition) {
+			value = i.partition[k].indexElement(value)
+		}
+		partition = append(partition, value)
+	}
+	return partition
+}
+
+// searchPrimary return primary of the object inside search index, shared search index
+// prefixes primary with object name
+func (i *Index) searchPrimary(primaryTuple tuple.Tuple) tuple.Tuple {
+	if i.global == nil {
+		return primaryTuple
+	}
+	return append(tuple.Tuple{i.object.name}, primaryTuple...)
+}
+
+// searchSource return index of the object and primary of the object the posting belongs to
+func (i *Index) searchSource(primaryTuple tuple.Tuple) (*Index, tuple.Tuple) {
+	if i.global == nil {
+		return i, primaryTuple
+	}
+	return i.global.source(primaryTuple)
+}
+
+// searchKey return key of search index: partition, word or trigram and primary key of the object
+func searchKey(partition tuple.Tuple, element string, primaryTuple tuple.Tuple) tuple.Tuple {
+	key := append(tuple.Tuple{}, partition...)
+	key = append(key, element)
+	return append(key, primaryTuple...)
+}
+
+// searchAnalyzer return analyzer of the search index
+func (i *Index) searchAnalyzer() Analyzer {
+	if i.analyzer != nil {
+		return i.analyzer
+	}
+	return AnalyzerDefault
+}
+
 func searchWordGeneralize(word string) string {
 	word = strings.ReplaceAll(word, "ё", "е")
 	return strings.ToLower(word)
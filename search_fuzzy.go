@@ -0,0 +1,276 @@
+package stored
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+const (
+	searchFuzzyCandidates = 200   // maximum number of objects checked by fuzzy search
+	searchFuzzyKeys       = 50000 // maximum number of trigram keys read by fuzzy search
+)
+
+// searchTrigrams will return set of character trigrams of the words, words are padded
+// so beginning and ending of the word produce own trigrams
+func searchTrigrams(words []string) map[string]bool {
+	trigrams := map[string]bool{}
+	for _, word := range words {
+		runes := []rune("$" + word + "$")
+		for k := 0; k+3 <= len(runes); k++ {
+			trigrams[string(runes[k:k+3])] = true
+		}
+	}
+	return trigrams
+}
+
+// searchMaxDistance return number of typos allowed for the word
+func searchMaxDistance(word string) int {
+	switch l := len([]rune(word)); {
+	case l <= 2:
+		return 0
+	case l <= 4:
+		return 1
+	}
+	return 2
+}
+
+// searchDistance return Damerau-Levenshtein (optimal string alignment) distance between words
+func searchDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for k := range d {
+		d[k] = make([]int, len(t)+1)
+		d[k][0] = k
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for k := 1; k <= len(s); k++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[k-1] == t[j-1] {
+				cost = 0
+			}
+			d[k][j] = minInt(d[k-1][j]+1, minInt(d[k][j-1]+1, d[k-1][j-1]+cost))
+			if k > 1 && j > 1 && s[k-1] == t[j-2] && s[k-2] == t[j-1] {
+				d[k][j] = minInt(d[k][j], d[k-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// writeTrigrams will set trigram keys of new words and delete ones not used anymore
+func (i *Index) writeTrigrams(tr fdb.Transaction, partition, primaryTuple tuple.Tuple, newWords, oldWords []string) {
+	toAdd := searchTrigrams(newWords)
+	for trigram := range searchTrigrams(oldWords) {
+		if !toAdd[trigram] {
+			tr.Clear(i.trigramDir.Pack(searchKey(partition, trigram, primaryTuple)))
+		}
+	}
+	for trigram := range toAdd {
+		tr.Set(i.trigramDir.Pack(searchKey(partition, trigram, primaryTuple)), []byte{})
+	}
+}
+
+// fuzzyCandidate is an object sharing trigrams with the query
+type fuzzyCandidate struct {
+	primary  tuple.Tuple
+	source   *Index      // index of the object, differs for shared search index
+	original tuple.Tuple // primary of the object
+	overlap  int
+	checks   []fdb.FutureByteSlice // keys of trigrams which postings were not read completely
+	need     *needObject
+	distance int
+	matched  int
+}
+
+// fuzzyTrigram is the trigram of the query with its postings read page by page
+type fuzzyTrigram struct {
+	trigram   string
+	begin     fdb.KeyConvertible // next key to read
+	end       fdb.KeyConvertible
+	pages     int
+	complete  bool // all the postings are read
+	primaries []tuple.Tuple
+	read      map[string]bool // primaries read
+	result    fdb.RangeResult
+}
+
+// runFuzzy performs typo tolerant search: objects sharing most trigrams with the query are
+// fetched and their words are compared with the query words using edit distance. Postings of
+// trigrams are read page by page until searchFuzzyKeys are read, so rare trigrams are read
+// completely first and only postings of the most common ones could be left unread
+func (sq *SearchQuery) runFuzzy(p *Promise, limit int, finish func(hits []SearchHit) Chain) Chain {
+	i := sq.index
+	if len(sq.terms) < 1 {
+		return p.fail(errors.New("No words found on the string"))
+	}
+	if len(sq.partition) != len(i.partition) {
+		return p.fail(ErrPartitionRequired)
+	}
+	trigrams := []*fuzzyTrigram{}
+	for trigram := range searchTrigrams(sq.terms) {
+		begin, end := i.trigramDir.Sub(searchKey(sq.partition, trigram, nil)...).FDBRangeKeys()
+		trigrams = append(trigrams, &fuzzyTrigram{trigram: trigram, begin: begin, end: end})
+	}
+	budget := searchFuzzyKeys
+	var read func() Chain
+	read = func() Chain {
+		reading := []*fuzzyTrigram{}
+		for _, trigram := range trigrams {
+			if trigram.complete || (trigram.pages > 0 && budget < searchTermLimit) {
+				continue
+			}
+			budget -= searchTermLimit
+			trigram.pages++
+			trigram.result = p.readTr.GetRange(fdb.KeyRange{Begin: trigram.begin, End: trigram.end}, fdb.RangeOptions{
+				Limit: searchTermLimit,
+			})
+			reading = append(reading, trigram)
+		}
+		if len(reading) == 0 {
+			return sq.fuzzyCandidates(p, trigrams, limit, finish)
+		}
+		return func() Chain {
+			for _, trigram := range reading {
+				rows, err := trigram.result.GetSliceWithError()
+				if err != nil {
+					return p.fail(err)
+				}
+				for _, row := range rows {
+					fullTuple, err := i.trigramDir.Sub(sq.partition...).Unpack(row.Key)
+					if err != nil {
+						return p.fail(err)
+					}
+					if len(fullTuple) < 2 {
+						continue
+					}
+					trigram.primaries = append(trigram.primaries, fullTuple[1:])
+				}
+				if len(rows) < searchTermLimit {
+					trigram.complete = true
+				} else {
+					trigram.begin = fdb.Key(append(append([]byte{}, rows[len(rows)-1].Key...), 0x00))
+				}
+			}
+			return read()
+		}
+	}
+	return read()
+}
+
+// fuzzyCandidates will rank objects by trigrams read, trigrams not read completely are checked
+// by keys for the best candidates, so candidates beyond the read postings still get full overlap
+func (sq *SearchQuery) fuzzyCandidates(p *Promise, trigrams []*fuzzyTrigram, limit int, finish func(hits []SearchHit) Chain) Chain {
+	i := sq.index
+	candidates := map[string]*fuzzyCandidate{}
+	order := []*fuzzyCandidate{}
+	for _, trigram := range trigrams {
+		trigram.read = map[string]bool{}
+		for _, primaryTuple := range trigram.primaries {
+			primaryKey := string(primaryTuple.Pack())
+			trigram.read[primaryKey] = true
+			candidate, ok := candidates[primaryKey]
+			if !ok {
+				candidate = &fuzzyCandidate{primary: primaryTuple}
+				candidates[primaryKey] = candidate
+				order = append(order, candidate)
+			}
+			candidate.overlap++
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return order[a].overlap > order[b].overlap
+	})
+	if len(order) > searchFuzzyCandidates {
+		order = order[:searchFuzzyCandidates]
+	}
+	for _, candidate := range order {
+		for _, trigram := range trigrams {
+			if trigram.complete || trigram.read[string(candidate.primary.Pack())] {
+				continue
+			}
+			key := i.trigramDir.Pack(searchKey(sq.partition, trigram.trigram, candidate.primary))
+			candidate.checks = append(candidate.checks, p.readTr.Get(key))
+		}
+		candidate.source, candidate.original = i.searchSource(candidate.primary)
+		if candidate.source != nil {
+			candidate.need = candidate.source.object.need(p.readTr, candidate.source.object.sub(candidate.original))
+		}
+	}
+	return func() Chain {
+		found := []*fuzzyCandidate{}
+		values := map[*fuzzyCandidate]*Value{}
+		for _, candidate := range order {
+			for _, check := range candidate.checks {
+				value, err := check.Get()
+				if err != nil {
+					return p.fail(err)
+				}
+				if value != nil {
+					candidate.overlap++
+				}
+			}
+			if candidate.need == nil {
+				continue
+			}
+			val, err := candidate.need.fetch()
+			if err != nil {
+				continue
+			}
+			words := searchGetInputWords(candidate.source, structAny(val.Interface()))
+			for _, term := range sq.terms {
+				best := -1
+				for _, word := range words {
+					distance := searchDistance(term, word)
+					if best == -1 || distance < best {
+						best = distance
+					}
+				}
+				if best != -1 && best <= searchMaxDistance(term) {
+					candidate.matched++
+					candidate.distance += best
+				}
+			}
+			if candidate.matched == 0 || (!sq.anyWord && candidate.matched != len(sq.terms)) {
+				continue
+			}
+			values[candidate] = val
+			found = append(found, candidate)
+		}
+		sort.SliceStable(found, func(a, b int) bool {
+			if found[a].matched != found[b].matched {
+				return found[a].matched > found[b].matched
+			}
+			if found[a].distance != found[b].distance {
+				return found[a].distance < found[b].distance
+			}
+			return found[a].overlap > found[b].overlap
+		})
+		if limit > 0 && len(found) > limit {
+			found = found[:limit]
+		}
+		hits := make([]SearchHit, len(found))
+		for k, candidate := range found {
+			hits[k] = SearchHit{
+				Object:  candidate.source.object.name,
+				Primary: candidate.original,
+				Score:   float64(candidate.overlap) / float64(1+candidate.distance),
+				Matched: candidate.matched,
+				Value:   values[candidate],
+			}
+		}
+		return finish(hits)
+	}
+}
@@ -0,0 +1,103 @@
+package stored
+
+import (
+	"sync"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+// SearchIndex is full text search index shared between several objects, so users, chats and
+// channels could be found using one query. Postings are same as IndexSearch has, but primary
+// of each object is prefixed with the object name
+type SearchIndex struct {
+	name    string
+	index   *Index // holds shared directories and options of the index
+	objects map[string]*Index
+	mux     sync.Mutex
+}
+
+// Name return name of the search index
+func (si *SearchIndex) Name() string {
+	return si.name
+}
+
+// Query will return search query for the text, see IndexSearch.Query
+func (si *SearchIndex) Query(text string) *SearchQuery {
+	return (&IndexSearch{index: si.index}).Query(text)
+}
+
+// Search will return objects of all attached types containing all the words of the query, see
+// IndexSearch.Search. Use Query(text).Hits() to know which object was found
+func (si *SearchIndex) Search(text string) *PromiseSlice {
+	return (&IndexSearch{index: si.index}).Search(text)
+}
+
+// Suggest will return most frequent terms starting with the prefix across all attached objects
+func (si *SearchIndex) Suggest(prefix string, limit int) *PromiseFacets {
+	return (&IndexSearch{index: si.index}).Suggest(prefix, limit)
+}
+
+// attach will register index of the object contributing to the search index
+func (si *SearchIndex) attach(index *Index) {
+	si.mux.Lock()
+	defer si.mux.Unlock()
+	si.objects[index.object.name] = index
+}
+
+// source return index of the object and primary of the object the posting belongs to
+func (si *SearchIndex) source(primaryTuple tuple.Tuple) (*Index, tuple.Tuple) {
+	if len(primaryTuple) < 2 {
+		return nil, nil
+	}
+	name, ok := primaryTuple[0].(string)
+	if !ok {
+		return nil, nil
+	}
+	si.mux.Lock()
+	index, ok := si.objects[name]
+	si.mux.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return index, primaryTuple[1:]
+}
+
+// clearObject will remove postings of the object only, other objects keep their data. Shared
+// index is read by batches of separate transactions
+func (si *SearchIndex) clearObject(object *Object) error {
+	i := si.index
+	err := clearPaged(object.db, i.dir, func(tr fdb.Transaction, row fdb.KeyValue) error {
+		key, err := i.dir.Unpack(row.Key)
+		if err != nil {
+			return err
+		}
+		if len(key) >= 2 && key[1] == object.name {
+			tr.Clear(row.Key)
+			tr.Add(i.statsDir.Sub(searchStatsTerm).Pack(tuple.Tuple{key[0]}), countDec)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = clearPaged(object.db, i.statsDir.Sub(searchStatsLength).Sub(object.name), func(tr fdb.Transaction, row fdb.KeyValue) error {
+		tr.Clear(row.Key)
+		tr.Add(i.statsDir.Pack(tuple.Tuple{searchStatsDocs}), countDec)
+		tr.Add(i.statsDir.Pack(tuple.Tuple{searchStatsTerms}), Int64(-ToInt64(row.Value)))
+		return nil
+	})
+	if err != nil || !i.fuzzy {
+		return err
+	}
+	return clearPaged(object.db, i.trigramDir, func(tr fdb.Transaction, row fdb.KeyValue) error {
+		key, err := i.trigramDir.Unpack(row.Key)
+		if err != nil {
+			return err
+		}
+		if len(key) >= 2 && key[1] == object.name {
+			tr.Clear(row.Key)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,486 @@
+package stored
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/subspace"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+const (
+	searchStatsLength = "length" // length of each document
+	searchStatsDocs   = "docs"   // number of indexed documents
+	searchStatsTerms  = "terms"  // total length of all indexed documents
+	searchStatsTerm   = "term"   // number of documents containing each term
+
+	searchTermLimit   = 1000  // maximum number of keys read for each term of the query
+	searchSuggestScan = 10000 // maximum number of terms read by suggestion
+	searchBM25K1      = 1.2
+	searchBM25B       = 0.75
+)
+
+// SearchHit is the object found by search query with its relevance
+type SearchHit struct {
+	Object  string      // name of the object found
+	Primary tuple.Tuple // primary key of the object found
+	Score   float64     // BM25 score of the object
+	Matched int         // number of query terms found inside the object
+	Value   *Value
+}
+
+// SearchQuery is the ranked search query builder
+type SearchQuery struct {
+	index     *Index
+	terms     []string
+	phrases   [][]string   // terms should go one by one
+	near      []searchNear // terms should be close to each other
+	partition tuple.Tuple
+	exact     map[string]bool // terms of quoted phrases and proximity conditions, matched as whole words
+	prefix    bool            // other terms are matched as beginning of the word
+	anyWord   bool
+	fuzzy     bool
+	limit     int
+}
+
+// searchNear is the proximity condition of two terms
+type searchNear struct {
+	a, b     string
+	distance int
+}
+
+var searchNearOperator = regexp.MustCompile("^NEAR/([0-9]+)$")
+
+// Query will return search query for the text, results are ranked best-first using BM25.
+// Quoted phrases match only adjacent words and «a NEAR/n b» matches words within n tokens
+func (is *IndexSearch) Query(text string) *SearchQuery {
+	sq := &SearchQuery{
+		index: is.index,
+		terms: []string{},
+		exact: map[string]bool{},
+		fuzzy: is.index.fuzzy,
+		limit: 100,
+	}
+	sq.parse(text)
+	return sq
+}
+
+// parse will split query text into terms, phrases and proximity conditions
+func (sq *SearchQuery) parse(text string) {
+	analyzer := sq.index.searchAnalyzer()
+	seen := map[string]bool{}
+	add := func(terms []string) {
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				sq.terms = append(sq.terms, term)
+			}
+		}
+	}
+	var previous []string // terms of the word or phrase before NEAR operator
+	near := -1
+	unit := func(terms []string) {
+		if len(terms) == 0 {
+			return
+		}
+		add(terms)
+		if near >= 0 && previous != nil {
+			sq.near = append(sq.near, searchNear{
+				a:        previous[len(previous)-1],
+				b:        terms[0],
+				distance: near,
+			})
+			sq.exact[previous[len(previous)-1]] = true
+			sq.exact[terms[0]] = true
+		}
+		previous = terms
+		near = -1
+	}
+	for k, part := range strings.Split(text, "\"") {
+		if k%2 == 1 { // inside quotes
+			phrase := analyzer.Analyze(part)
+			if len(phrase) > 1 {
+				sq.phrases = append(sq.phrases, phrase)
+			}
+			unit(phrase)
+			for _, term := range phrase {
+				sq.exact[term] = true
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if match := searchNearOperator.FindStringSubmatch(word); match != nil {
+				near, _ = strconv.Atoi(match[1])
+				continue
+			}
+			unit(analyzer.Analyze(word))
+		}
+	}
+}
+
+// Prefix will match words of the query as beginning of the word, so objects could be found while
+// user is typing. Quoted words and words of NEAR conditions are always matched exactly
+func (sq *SearchQuery) Prefix() *SearchQuery {
+	sq.prefix = true
+	return sq
+}
+
+// Exact will disable typo tolerance of the fuzzy search index, words will be matched exactly
+func (sq *SearchQuery) Exact() *SearchQuery {
+	sq.fuzzy = false
+	return sq
+}
+
+// In restricts search to the partition of partitioned search index
+func (sq *SearchQuery) In(partition ...interface{}) *SearchQuery {
+	sq.partition = sq.index.encodePartition(partition)
+	return sq
+}
+
+// AnyWord will return objects matching at least one word of the query,
+// objects matching more words go first
+func (sq *SearchQuery) AnyWord() *SearchQuery {
+	sq.anyWord = true
+	return sq
+}
+
+// Limit sets maximum number of results
+func (sq *SearchQuery) Limit(limit int) *SearchQuery {
+	sq.limit = limit
+	return sq
+}
+
+// Hits will return found objects with scores
+func (sq *SearchQuery) Hits() ([]SearchHit, error) {
+	return sq.PromiseHits().Hits()
+}
+
+// PromiseHits will return promise of found objects with scores
+func (sq *SearchQuery) PromiseHits() *PromiseHits {
+	p := sq.index.object.promiseHits()
+	p.doRead(func() Chain {
+		return sq.run(&p.Promise, sq.limit, func(hits []SearchHit) Chain {
+			return p.done(hits)
+		})
+	})
+	return p
+}
+
+// Promise will return promise of found objects ordered by relevance
+func (sq *SearchQuery) Promise() *PromiseSlice {
+	p := sq.index.object.promiseSlice()
+	p.limit = sq.limit
+	p.doRead(func() Chain {
+		return sq.run(&p.Promise, p.limit, p.doneHits)
+	})
+	return p
+}
+
+// ScanAll will fill slice with found objects ordered by relevance
+func (sq *SearchQuery) ScanAll(slicePointer interface{}) error {
+	return sq.Promise().ScanAll(slicePointer)
+}
+
+// Slice will return slice of found objects ordered by relevance
+func (sq *SearchQuery) Slice() *Slice {
+	return sq.Promise().Slice()
+}
+
+// Do will return promise of the search attached to the transaction
+func (sq *SearchQuery) Do(tr *Transaction) *PromiseSlice {
+	return sq.Promise().Do(tr)
+}
+
+// TryAll performs search within the transaction, see PromiseSlice.TryAll
+func (sq *SearchQuery) TryAll(tr *Transaction, slicePointer interface{}) {
+	sq.Promise().TryAll(tr, slicePointer)
+}
+
+// CheckAll performs search within the transaction, see PromiseSlice.CheckAll
+func (sq *SearchQuery) CheckAll(tr *Transaction, slicePointer interface{}) {
+	sq.Promise().CheckAll(tr, slicePointer)
+}
+
+// searchCandidate collects matches of one object
+type searchCandidate struct {
+	primary   tuple.Tuple
+	frequency map[string]int64
+	positions map[string]map[int64]bool
+	length    fdb.FutureByteSlice
+	score     float64
+}
+
+// run performs search, ranks and fetches found objects
+func (sq *SearchQuery) run(p *Promise, limit int, finish func(hits []SearchHit) Chain) Chain {
+	if sq.fuzzy && sq.phrases == nil && sq.near == nil { // positions are matched exactly
+		return sq.runFuzzy(p, limit, finish)
+	}
+	i := sq.index
+	if len(sq.terms) < 1 {
+		return p.fail(errors.New("No words found on the string"))
+	}
+	if len(sq.partition) != len(i.partition) {
+		return p.fail(ErrPartitionRequired)
+	}
+	sub := i.dir.Sub(sq.partition...)
+	rangeResults := make([]fdb.RangeResult, len(sq.terms))
+	for k, term := range sq.terms {
+		rangeResults[k] = p.readTr.GetRange(sq.termRange(sub, term), fdb.RangeOptions{
+			Limit: searchTermLimit,
+		})
+	}
+	// document frequency is read from term counters, postings are truncated for common terms
+	dfFutures := make([]fdb.FutureByteSlice, len(sq.terms))
+	for k, term := range sq.terms {
+		dfFutures[k] = p.readTr.Get(i.statsDir.Sub(searchStatsTerm).Pack(searchKey(sq.partition, term, nil)))
+	}
+	docsFuture := p.readTr.Get(i.statsDir.Pack(tuple.Tuple{searchStatsDocs}))
+	termsFuture := p.readTr.Get(i.statsDir.Pack(tuple.Tuple{searchStatsTerms}))
+	return func() Chain {
+		candidates := map[string]*searchCandidate{}
+		order := []*searchCandidate{}
+		documentFrequency := map[string]int{}
+		for k, term := range sq.terms {
+			rows, err := rangeResults[k].GetSliceWithError()
+			if err != nil {
+				return p.fail(err)
+			}
+			for _, row := range rows {
+				fullTuple, err := sub.Unpack(row.Key)
+				if err != nil {
+					return p.fail(err)
+				}
+				if len(fullTuple) < 2 {
+					continue
+				}
+				primaryTuple := fullTuple[1:]
+				primaryKey := string(primaryTuple.Pack())
+				candidate, ok := candidates[primaryKey]
+				if !ok {
+					candidate = &searchCandidate{
+						primary:   primaryTuple,
+						frequency: map[string]int64{},
+						positions: map[string]map[int64]bool{},
+					}
+					candidates[primaryKey] = candidate
+					order = append(order, candidate)
+				}
+				if candidate.frequency[term] == 0 {
+					documentFrequency[term]++
+				}
+				frequency, positions, err := searchPosting(row.Value)
+				if err != nil {
+					return p.fail(err)
+				}
+				candidate.frequency[term] += frequency
+				if candidate.positions[term] == nil {
+					candidate.positions[term] = map[int64]bool{}
+				}
+				for _, position := range positions {
+					candidate.positions[term][position] = true
+				}
+			}
+		}
+		found := []*searchCandidate{}
+		for _, candidate := range order {
+			if (sq.anyWord || len(candidate.frequency) == len(sq.terms)) && sq.matchPositions(candidate) {
+				candidate.length = p.readTr.Get(i.statsDir.Sub(searchStatsLength).Pack(candidate.primary))
+				found = append(found, candidate)
+			}
+		}
+		return func() Chain {
+			docs, err := searchStat(docsFuture)
+			if err != nil {
+				return p.fail(err)
+			}
+			total, err := searchStat(termsFuture)
+			if err != nil {
+				return p.fail(err)
+			}
+			for k, term := range sq.terms {
+				df, err := searchStat(dfFutures[k])
+				if err != nil {
+					return p.fail(err)
+				}
+				// prefix term counts documents of all the words starting with it, so counter is lower
+				if df > int64(documentFrequency[term]) {
+					documentFrequency[term] = int(df)
+				}
+			}
+			if docs < int64(len(order)) {
+				docs = int64(len(order))
+			}
+			averageLength := 1.0
+			if docs != 0 && total != 0 {
+				averageLength = float64(total) / float64(docs)
+			}
+			for _, candidate := range found {
+				length, err := searchStat(candidate.length)
+				if err != nil {
+					return p.fail(err)
+				}
+				if length == 0 {
+					length = int64(averageLength)
+				}
+				for term, frequency := range candidate.frequency {
+					df := float64(documentFrequency[term])
+					idf := math.Log(1 + (float64(docs)-df+0.5)/(df+0.5))
+					tf := float64(frequency)
+					norm := 1 - searchBM25B + searchBM25B*float64(length)/averageLength
+					candidate.score += idf * tf * (searchBM25K1 + 1) / (tf + searchBM25K1*norm)
+				}
+			}
+			sort.SliceStable(found, func(a, b int) bool {
+				if len(found[a].frequency) != len(found[b].frequency) {
+					return len(found[a].frequency) > len(found[b].frequency)
+				}
+				return found[a].score > found[b].score
+			})
+			if limit > 0 && len(found) > limit {
+				found = found[:limit]
+			}
+			need := make([]*needObject, len(found))
+			primaries := make([]tuple.Tuple, len(found))
+			for k, candidate := range found {
+				source, primaryTuple := i.searchSource(candidate.primary)
+				if source == nil { // object is not attached to the shared index anymore
+					continue
+				}
+				need[k] = source.object.need(p.readTr, source.object.sub(primaryTuple))
+				primaries[k] = primaryTuple
+			}
+			return func() Chain {
+				hits := []SearchHit{}
+				for k, n := range need {
+					if n == nil {
+						continue
+					}
+					val, err := n.fetch()
+					if err != nil {
+						continue
+					}
+					hits = append(hits, SearchHit{
+						Object:  n.object.name,
+						Primary: primaries[k],
+						Score:   found[k].score,
+						Matched: len(found[k].frequency),
+						Value:   val,
+					})
+				}
+				return finish(hits)
+			}
+		}
+	}
+}
+
+// termRange return range of postings of the term, terms of prefix query are matched as prefix
+// unless they are quoted or part of proximity condition
+func (sq *SearchQuery) termRange(sub subspace.Subspace, term string) fdb.KeyRange {
+	if sq.prefix && !sq.positional(term) {
+		return searchPrefixRange(sub, term)
+	}
+	start, end := sub.Sub(term).FDBRangeKeys()
+	return fdb.KeyRange{Begin: start, End: end}
+}
+
+// positional return true if the term is quoted or part of proximity condition
+func (sq *SearchQuery) positional(term string) bool {
+	return sq.exact[term]
+}
+
+// matchPositions checks phrases and proximity conditions of the query
+func (sq *SearchQuery) matchPositions(candidate *searchCandidate) bool {
+	for _, phrase := range sq.phrases {
+		found := false
+		for start := range candidate.positions[phrase[0]] {
+			found = true
+			for k, term := range phrase[1:] {
+				if !candidate.positions[term][start+int64(k)+1] {
+					found = false
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, near := range sq.near {
+		found := false
+		for a := range candidate.positions[near.a] {
+			for b := range candidate.positions[near.b] {
+				distance := a - b
+				if distance < 0 {
+					distance = -distance
+				}
+				if distance <= int64(near.distance) {
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// searchPosting decodes value of search index key: term frequency and positions of the word.
+// Keys written before ranking has no value, keys written before positions has only frequency
+func searchPosting(value []byte) (int64, []int64, error) {
+	if len(value) < 8 {
+		return 1, nil, nil
+	}
+	frequency := ToInt64(value[:8])
+	if len(value) == 8 {
+		return frequency, nil, nil
+	}
+	positionsTuple, err := tuple.Unpack(value[8:])
+	if err != nil {
+		return 0, nil, err
+	}
+	positions := make([]int64, 0, len(positionsTuple))
+	for _, element := range positionsTuple {
+		position, ok := element.(int64)
+		if !ok {
+			return 0, nil, ErrDataCorrupt
+		}
+		positions = append(positions, position)
+	}
+	return frequency, positions, nil
+}
+
+// searchPrefixRange return range of keys of the subspace which first element is string starting with prefix
+func searchPrefixRange(sub subspace.Subspace, prefix string) fdb.KeyRange {
+	partword := sub.Pack(tuple.Tuple{prefix})
+	start := partword[:len(partword)-1]
+	end := make(fdb.Key, len(start))
+	copy(end, start)
+
+	start = append(start, 0)
+	end = append(end, 255)
+	return fdb.KeyRange{Begin: start, End: end}
+}
+
+func searchStat(future fdb.FutureByteSlice) (int64, error) {
+	bytes, err := future.Get()
+	if err != nil {
+		return 0, err
+	}
+	if len(bytes) == 0 {
+		return 0, nil
+	}
+	return ToInt64(bytes), nil
+}
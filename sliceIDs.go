@@ -65,7 +65,7 @@ func (s *SliceIDs) ScanAll(slicePointer interface{}) (e error) {
 		newStruct = newStruct.Elem()
 
 		if len(key) != len(s.object.primaryFields) {
-			s.object.panic("ScanAll of swype ids, primary keys count mismatch")
+			return &CorruptionError{Key: s.object.primary.Pack(key), Reason: "primary keys count mismatch"}
 		}
 		for num, field := range s.object.primaryFields {
 			keyInterface := key[num]
@@ -0,0 +1,261 @@
+package stored
+
+import "strings"
+
+// StemEnglish implements Porter2 (Snowball) english stemmer
+func StemEnglish(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	if exception, ok := englishExceptions[word]; ok {
+		return exception
+	}
+	w := []byte(strings.TrimPrefix(word, "'"))
+	for k := range w { // y acting as consonant
+		if w[k] == 'y' && (k == 0 || englishVowel(w[k-1])) {
+			w[k] = 'Y'
+		}
+	}
+	r1, r2 := englishRegions(w)
+
+	w = englishStep0(w)
+	w = englishStep1a(w)
+	if englishInvariant[string(w)] {
+		return string(w)
+	}
+	w = englishStep1b(w, r1)
+	w = englishStep1c(w)
+	w = englishStep2(w, r1)
+	w = englishStep3(w, r1, r2)
+	w = englishStep4(w, r2)
+	w = englishStep5(w, r1, r2)
+	return strings.ReplaceAll(string(w), "Y", "y")
+}
+
+var englishExceptions = map[string]string{
+	"skis": "ski", "skies": "sky", "dying": "die", "lying": "lie", "tying": "tie",
+	"idly": "idl", "gently": "gentl", "ugly": "ugli", "early": "earli", "only": "onli",
+	"singly": "singl", "sky": "sky", "news": "news", "howe": "howe", "atlas": "atlas",
+	"cosmos": "cosmos", "bias": "bias", "andes": "andes",
+}
+
+var englishInvariant = map[string]bool{
+	"inning": true, "outing": true, "canning": true, "herring": true,
+	"earring": true, "proceed": true, "exceed": true, "succeed": true,
+}
+
+func englishVowel(c byte) bool {
+	switch c {
+	case 'a', 'e', 'i', 'o', 'u', 'y':
+		return true
+	}
+	return false
+}
+
+func englishDouble(w []byte) bool {
+	if len(w) < 2 || w[len(w)-1] != w[len(w)-2] {
+		return false
+	}
+	switch w[len(w)-1] {
+	case 'b', 'd', 'f', 'g', 'm', 'n', 'p', 'r', 't':
+		return true
+	}
+	return false
+}
+
+// englishRegions return start of R1 and R2 regions
+func englishRegions(w []byte) (r1, r2 int) {
+	next := func(from int) int {
+		for k := from + 1; k < len(w); k++ {
+			if !englishVowel(w[k]) && englishVowel(w[k-1]) {
+				return k + 1
+			}
+		}
+		return len(w)
+	}
+	r1 = next(0)
+	for _, prefix := range []string{"gener", "commun", "arsen"} {
+		if strings.HasPrefix(string(w), prefix) {
+			r1 = len(prefix)
+		}
+	}
+	r2 = next(r1)
+	return
+}
+
+// englishShortSyllable checks if word ends with short syllable
+func englishShortSyllable(w []byte) bool {
+	l := len(w)
+	if l == 2 {
+		return englishVowel(w[0]) && !englishVowel(w[1])
+	}
+	if l < 3 {
+		return false
+	}
+	c := w[l-1]
+	return !englishVowel(w[l-3]) && englishVowel(w[l-2]) && !englishVowel(c) && c != 'w' && c != 'x' && c != 'Y'
+}
+
+func englishHasVowel(w []byte) bool {
+	for _, c := range w {
+		if englishVowel(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// englishSuffix return longest suffix from list word ends with
+func englishSuffix(w []byte, suffixes ...string) string {
+	found := ""
+	for _, suffix := range suffixes {
+		if len(suffix) > len(found) && strings.HasSuffix(string(w), suffix) {
+			found = suffix
+		}
+	}
+	return found
+}
+
+func englishReplace(w []byte, suffix, replacement string) []byte {
+	return append(w[:len(w)-len(suffix)], replacement...)
+}
+
+func englishStep0(w []byte) []byte {
+	suffix := englishSuffix(w, "'", "'s", "'s'")
+	return w[:len(w)-len(suffix)]
+}
+
+func englishStep1a(w []byte) []byte {
+	switch suffix := englishSuffix(w, "sses", "ied", "ies", "s", "us", "ss"); suffix {
+	case "sses":
+		return englishReplace(w, suffix, "ss")
+	case "ied", "ies":
+		if len(w) > 4 {
+			return englishReplace(w, suffix, "i")
+		}
+		return englishReplace(w, suffix, "ie")
+	case "s":
+		if len(w) > 2 && englishHasVowel(w[:len(w)-2]) {
+			return w[:len(w)-1]
+		}
+	}
+	return w
+}
+
+func englishStep1b(w []byte, r1 int) []byte {
+	switch suffix := englishSuffix(w, "eed", "eedly", "ed", "edly", "ing", "ingly"); suffix {
+	case "eed", "eedly":
+		if len(w)-len(suffix) >= r1 {
+			return englishReplace(w, suffix, "ee")
+		}
+	case "ed", "edly", "ing", "ingly":
+		stem := w[:len(w)-len(suffix)]
+		if !englishHasVowel(stem) {
+			return w
+		}
+		if englishSuffix(stem, "at", "bl", "iz") != "" {
+			return append(stem, 'e')
+		}
+		if englishDouble(stem) {
+			return stem[:len(stem)-1]
+		}
+		if englishShortSyllable(stem) && r1 >= len(stem) {
+			return append(stem, 'e')
+		}
+		return stem
+	}
+	return w
+}
+
+func englishStep1c(w []byte) []byte {
+	l := len(w)
+	if l > 2 && (w[l-1] == 'y' || w[l-1] == 'Y') && !englishVowel(w[l-2]) {
+		w[l-1] = 'i'
+	}
+	return w
+}
+
+var englishStep2Suffixes = map[string]string{
+	"tional": "tion", "enci": "ence", "anci": "ance", "abli": "able", "entli": "ent",
+	"izer": "ize", "ization": "ize", "ational": "ate", "ation": "ate", "ator": "ate",
+	"alism": "al", "aliti": "al", "alli": "al", "fulness": "ful", "ousli": "ous",
+	"ousness": "ous", "iveness": "ive", "iviti": "ive", "biliti": "ble", "bli": "ble",
+	"ogi": "og", "fulli": "ful", "lessli": "less", "li": "",
+}
+
+var englishStep3Suffixes = map[string]string{
+	"tional": "tion", "ational": "ate", "alize": "al", "icate": "ic", "iciti": "ic",
+	"ical": "ic", "ful": "", "ness": "", "ative": "",
+}
+
+func englishKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+var englishStep2List = englishKeys(englishStep2Suffixes)
+var englishStep3List = englishKeys(englishStep3Suffixes)
+
+func englishStep2(w []byte, r1 int) []byte {
+	suffix := englishSuffix(w, englishStep2List...)
+	if suffix == "" || len(w)-len(suffix) < r1 {
+		return w
+	}
+	stem := w[:len(w)-len(suffix)]
+	switch suffix {
+	case "ogi":
+		if len(stem) == 0 || stem[len(stem)-1] != 'l' {
+			return w
+		}
+	case "li":
+		if len(stem) == 0 || !strings.ContainsRune("cdeghkmnrt", rune(stem[len(stem)-1])) {
+			return w
+		}
+	}
+	return englishReplace(w, suffix, englishStep2Suffixes[suffix])
+}
+
+func englishStep3(w []byte, r1, r2 int) []byte {
+	suffix := englishSuffix(w, englishStep3List...)
+	if suffix == "" || len(w)-len(suffix) < r1 {
+		return w
+	}
+	if suffix == "ative" && len(w)-len(suffix) < r2 {
+		return w
+	}
+	return englishReplace(w, suffix, englishStep3Suffixes[suffix])
+}
+
+func englishStep4(w []byte, r2 int) []byte {
+	suffix := englishSuffix(w, "al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement",
+		"ment", "ent", "ism", "ate", "iti", "ous", "ive", "ize", "ion")
+	if suffix == "" || len(w)-len(suffix) < r2 {
+		return w
+	}
+	stem := w[:len(w)-len(suffix)]
+	if suffix == "ion" && (len(stem) == 0 || (stem[len(stem)-1] != 's' && stem[len(stem)-1] != 't')) {
+		return w
+	}
+	return stem
+}
+
+func englishStep5(w []byte, r1, r2 int) []byte {
+	l := len(w)
+	if l == 0 {
+		return w
+	}
+	switch w[l-1] {
+	case 'e':
+		if l-1 >= r2 || (l-1 >= r1 && !englishShortSyllable(w[:l-1])) {
+			return w[:l-1]
+		}
+	case 'l':
+		if l-1 >= r2 && l > 1 && w[l-2] == 'l' {
+			return w[:l-1]
+		}
+	}
+	return w
+}
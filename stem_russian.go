@@ -0,0 +1,133 @@
+package stored
+
+import "strings"
+
+// StemRussian implements Snowball russian stemmer
+func StemRussian(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ё", "е"))
+	rv, r2 := russianRegions(w)
+	if rv >= len(w) {
+		return string(w)
+	}
+
+	// step 1
+	if stem, ok := russianRemove(w, rv, russianGerund1, russianGerund2); ok {
+		w = stem
+	} else {
+		if stem, ok := russianRemove(w, rv, nil, russianReflexive); ok {
+			w = stem
+		}
+		if stem, ok := russianRemove(w, rv, nil, russianAdjective); ok {
+			w = stem
+			if stem, ok := russianRemove(w, rv, russianParticiple1, russianParticiple2); ok {
+				w = stem
+			}
+		} else if stem, ok := russianRemove(w, rv, russianVerb1, russianVerb2); ok {
+			w = stem
+		} else if stem, ok := russianRemove(w, rv, nil, russianNoun); ok {
+			w = stem
+		}
+	}
+
+	// step 2
+	if stem, ok := russianRemove(w, rv, nil, []string{"и"}); ok {
+		w = stem
+	}
+
+	// step 3
+	if stem, ok := russianRemove(w, r2, nil, []string{"ост", "ость"}); ok {
+		w = stem
+	}
+
+	// step 4
+	if stem, ok := russianRemove(w, rv, nil, []string{"нн"}); ok {
+		return string(append(stem, 'н'))
+	}
+	if stem, ok := russianRemove(w, rv, nil, []string{"ейш", "ейше"}); ok {
+		w = stem
+		if stem, ok := russianRemove(w, rv, nil, []string{"нн"}); ok {
+			w = append(stem, 'н')
+		}
+		return string(w)
+	}
+	if stem, ok := russianRemove(w, rv, nil, []string{"ь"}); ok {
+		w = stem
+	}
+	return string(w)
+}
+
+var (
+	russianGerund1     = []string{"в", "вши", "вшись"}
+	russianGerund2     = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	russianReflexive   = []string{"ся", "сь"}
+	russianParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	russianParticiple2 = []string{"ивш", "ывш", "ующ"}
+	russianAdjective   = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им",
+		"ым", "ом", "его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	russianVerb1 = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют",
+		"ны", "ть", "ешь", "нно"}
+	russianVerb2 = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил",
+		"ыл", "им", "ым", "ен", "ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть",
+		"ишь", "ую", "ю"}
+	russianNoun = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией",
+		"ей", "ой", "ий", "й", "иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь",
+		"ию", "ью", "ю", "ия", "ья", "я"}
+)
+
+func russianVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// russianRegions return start of RV and R2 regions
+func russianRegions(w []rune) (rv, r2 int) {
+	rv = len(w)
+	for k, r := range w {
+		if russianVowel(r) {
+			rv = k + 1
+			break
+		}
+	}
+	next := func(from int) int {
+		for k := from + 1; k < len(w); k++ {
+			if !russianVowel(w[k]) && russianVowel(w[k-1]) {
+				return k + 1
+			}
+		}
+		return len(w)
+	}
+	r1 := next(0)
+	r2 = next(r1)
+	return
+}
+
+// russianRemove removes longest suffix found inside the region, suffixes of first group
+// should be preceded by «а» or «я»
+func russianRemove(w []rune, region int, group1, group2 []string) ([]rune, bool) {
+	found := 0
+	for _, suffix := range group1 {
+		l := len([]rune(suffix))
+		if l > found && russianEnds(w, region, suffix) && len(w)-l-1 >= region {
+			if prev := w[len(w)-l-1]; prev == 'а' || prev == 'я' {
+				found = l
+			}
+		}
+	}
+	for _, suffix := range group2 {
+		l := len([]rune(suffix))
+		if l > found && russianEnds(w, region, suffix) {
+			found = l
+		}
+	}
+	if found == 0 {
+		return w, false
+	}
+	return w[:len(w)-found], true
+}
+
+func russianEnds(w []rune, region int, suffix string) bool {
+	s := []rune(suffix)
+	if len(w)-len(s) < region {
+		return false
+	}
+	return string(w[len(w)-len(s):]) == suffix
+}
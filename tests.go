@@ -1,13 +1,18 @@
 package stored
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 	"github.com/capturetechnologies/stored/packed"
+	"github.com/mmcloughlin/geohash"
 )
 
 type user struct {
@@ -242,7 +247,7 @@ func testsClear(dir *Directory) error {
 	newUser := user{ID: 1}
 	err = smUser.Get(&newUser).Err()
 	if err != nil {
-		if err == ErrNotFound {
+		if errors.Is(err, ErrNotFound) {
 			return nil
 		}
 		return err
@@ -1037,36 +1042,2245 @@ func testsGeoIndex(dir *Directory) error {
 	return nil
 }
 
-func testsSingleField(dir *Directory) error {
+func testsGeoRadius(dir *Directory) error {
+	type place struct {
+		ID   int     `stored:"id"`
+		Lat  float64 `stored:"lat"`
+		Long float64 `stored:"long"`
+	}
+	g := dir.Object("geo_radius", place{})
+	g.Primary("id")
+	indexGeo := g.IndexGeo("lat", "long", 7)
+	dbGeo := g.Done()
+	dbGeo.Clear()
+
+	places := []place{
+		{ID: 1, Lat: 55.7558, Long: 37.6173}, // center
+		{ID: 2, Lat: 55.7600, Long: 37.6200}, // ~500m
+		{ID: 3, Lat: 55.7700, Long: 37.6400}, // ~2km
+		{ID: 4, Lat: 55.8500, Long: 37.6173}, // ~10km
+		{ID: 5, Lat: 59.9343, Long: 30.3351}, // other city
+	}
+	for k := range places {
+		err := dbGeo.Set(&places[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	hits, err := indexGeo.Query(55.7560, 37.6175).Radius(3000).Hits()
+	if err != nil {
+		return err
+	}
+	if len(hits) != 3 {
+		return fmt.Errorf("incorrect hits count %d instead of 3", len(hits))
+	}
+	for k, id := range []int{1, 2, 3} {
+		found := place{}
+		err = hits[k].Value.Scan(&found)
+		if err != nil {
+			return err
+		}
+		if found.ID != id {
+			return fmt.Errorf("hit %d is %d instead of %d", k, found.ID, id)
+		}
+		if k > 0 && hits[k].Distance < hits[k-1].Distance {
+			return fmt.Errorf("hits are not sorted by distance")
+		}
+	}
+	if hits[2].Distance < 1000 || hits[2].Distance > 3000 {
+		return fmt.Errorf("incorrect distance %f", hits[2].Distance)
+	}
+
+	rows := []place{}
+	err = indexGeo.Query(55.7560, 37.6175).Radius(20000).Limit(2).ScanAll(&rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 2 || rows[0].ID != 1 || rows[1].ID != 2 {
+		return fmt.Errorf("incorrect nearest rows %v", rows)
+	}
+	rows = []place{}
+	err = indexGeo.Query(55.7560, 37.6175).Radius(20000).ScanAll(&rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 4 {
+		return fmt.Errorf("incorrect rows count %d instead of 4", len(rows))
+	}
+	return nil
+}
+
+func testsGeoScanPaging(dir *Directory) error {
+	type place struct {
+		ID   int     `stored:"id"`
+		Lat  float64 `stored:"lat"`
+		Long float64 `stored:"long"`
+	}
+	g := dir.Object("geo_scan_paging", place{})
+	g.Primary("id")
+	indexGeo := g.IndexGeo("lat", "long", 7)
+	dbGeo := g.Done()
+	dbGeo.Clear()
+
+	total := geoScanLimit + 10 // single cell holds more keys than read at once
+	for from := 1; from <= total; from += 500 {
+		err := dir.Write(func(tr *Transaction) {
+			for id := from; id < from+500 && id <= total; id++ {
+				dbGeo.Set(&place{ID: id, Lat: 55.7600, Long: 37.6200}).Check(tr)
+			}
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err := dbGeo.Set(&place{ID: total + 1, Lat: 55.7558, Long: 37.6173}).Err()
+	if err != nil {
+		return err
+	}
+	hits, err := indexGeo.Query(55.7560, 37.6175).Radius(1000).Limit(1).Hits()
+	if err != nil {
+		return err
+	}
+	if len(hits) != 1 || hits[0].Distance > 100 {
+		return fmt.Errorf("closest object of crowded cell not found: %v", hits)
+	}
+	rows := []place{}
+	err = indexGeo.Query(55.7560, 37.6175).Radius(1000).ScanAll(&rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) != total+1 {
+		return fmt.Errorf("crowded cell returned %d objects instead of %d", len(rows), total+1)
+	}
+	return nil
+}
+
+func testsGeoNearest(dir *Directory) error {
+	type place struct {
+		ID   int     `stored:"id"`
+		Lat  float64 `stored:"lat"`
+		Long float64 `stored:"long"`
+	}
+	g := dir.Object("geo_nearest", place{})
+	g.Primary("id")
+	indexGeo := g.IndexGeo("lat", "long", 7)
+	dbGeo := g.Done()
+	dbGeo.Clear()
+
+	places := []place{
+		{ID: 1, Lat: 55.7558, Long: 37.6173},
+		{ID: 2, Lat: 55.7600, Long: 37.6200},
+		{ID: 3, Lat: 55.7700, Long: 37.6400},
+		{ID: 4, Lat: 55.8500, Long: 37.6173},
+		{ID: 5, Lat: 59.9343, Long: 30.3351},
+	}
+	for k := range places {
+		err := dbGeo.Set(&places[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	ids := func(query *GeoQuery) ([]int, error) {
+		rows := []place{}
+		err := query.ScanAll(&rows)
+		res := []int{}
+		for _, row := range rows {
+			res = append(res, row.ID)
+		}
+		return res, err
+	}
+	found, err := ids(indexGeo.Nearest(55.7560, 37.6175, 2))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[1 2]" {
+		return fmt.Errorf("incorrect nearest %v", found)
+	}
+	found, err = ids(indexGeo.Nearest(55.7560, 37.6175, 5)) // rings should expand up to other city
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[1 2 3 4 5]" {
+		return fmt.Errorf("incorrect nearest %v", found)
+	}
+	found, err = ids(indexGeo.Within(GeoBox{MinLat: 55.75, MinLong: 37.61, MaxLat: 55.78, MaxLong: 37.65}))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[3 2 1]" && fmt.Sprint(found) != "[2 3 1]" {
+		return fmt.Errorf("incorrect objects within the box %v", found)
+	}
+	found, err = ids(indexGeo.WithinPolygon([]GeoPoint{
+		{Lat: 55.75, Long: 37.61},
+		{Lat: 55.75, Long: 37.65},
+		{Lat: 55.78, Long: 37.61},
+	}))
+	if err != nil {
+		return err
+	}
+	if len(found) != 2 || found[0]+found[1] != 3 {
+		return fmt.Errorf("incorrect objects within the polygon %v", found)
+	}
+
+	antimeridian := []place{
+		{ID: 6, Lat: 0.5, Long: 179.9},
+		{ID: 7, Lat: 0.5, Long: -179.9},
+	}
+	for k := range antimeridian {
+		err = dbGeo.Set(&antimeridian[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	found, err = ids(indexGeo.Within(GeoBox{MinLat: 0, MinLong: 179.5, MaxLat: 1, MaxLong: -179.5}))
+	if err != nil {
+		return err
+	}
+	if len(found) != 2 || found[0]+found[1] != 13 {
+		return fmt.Errorf("incorrect objects within the box crossing antimeridian %v", found)
+	}
+	found, err = ids(indexGeo.Nearest(0.5, 179.99, 2))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[6 7]" {
+		return fmt.Errorf("incorrect nearest across antimeridian %v", found)
+	}
+	return nil
+}
+
+func testsGeoClusters(dir *Directory) error {
+	type place struct {
+		ID   int     `stored:"id"`
+		Lat  float64 `stored:"lat"`
+		Long float64 `stored:"long"`
+	}
+	g := dir.Object("geo_clusters", place{})
+	g.Primary("id")
+	indexGeo := g.IndexGeo("lat", "long", 7).Counted()
+	dbGeo := g.Done()
+	dbGeo.Clear()
+
+	places := []place{
+		{ID: 1, Lat: 55.7558, Long: 37.6173},
+		{ID: 2, Lat: 55.7559, Long: 37.6174},
+		{ID: 3, Lat: 55.7700, Long: 37.6400},
+		{ID: 4, Lat: 59.9343, Long: 30.3351},
+	}
+	for k := range places {
+		err := dbGeo.Set(&places[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	box := GeoBox{MinLat: 55.70, MinLong: 37.50, MaxLat: 55.80, MaxLong: 37.70}
+	counts := func(precision int) (map[string]int64, error) {
+		facets, err := indexGeo.Clusters(box, precision).Facets()
+		res := map[string]int64{}
+		for _, facet := range facets {
+			res[facet.Value.(string)] = facet.Count
+		}
+		return res, err
+	}
+	clusters, err := counts(4)
+	if err != nil {
+		return err
+	}
+	if len(clusters) != 1 || clusters["ucfv"] != 3 {
+		return fmt.Errorf("incorrect clusters %v", clusters)
+	}
+	clusters, err = counts(7)
+	if err != nil {
+		return err
+	}
+	if len(clusters) != 2 || clusters[geohash.EncodeWithPrecision(55.7558, 37.6173, 7)] != 2 {
+		return fmt.Errorf("incorrect clusters %v", clusters)
+	}
+
+	places[0].Lat = 59.9344 // moved to other city
+	places[0].Long = 30.3352
+	err = dbGeo.Set(&places[0]).Err()
+	if err != nil {
+		return err
+	}
+	err = dbGeo.Delete(&places[2]).Err()
+	if err != nil {
+		return err
+	}
+	clusters, err = counts(4)
+	if err != nil {
+		return err
+	}
+	if len(clusters) != 1 || clusters["ucfv"] != 1 {
+		return fmt.Errorf("incorrect clusters after move %v", clusters)
+	}
+	count, err := dbGeo.Use("lat,long:7").List().Count().Int64() // geo counters layout
+	if err != nil {
+		return err
+	}
+	if count != 3 {
+		return fmt.Errorf("geo index count is %d instead of 3", count)
+	}
+	count, err = dbGeo.Use("lat,long:7").List("ucfv").Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 1 {
+		return fmt.Errorf("geo cell count is %d instead of 1", count)
+	}
+	return nil
+}
+
+func testsGeoPartition(dir *Directory) error {
+	type place struct {
+		ID       int     `stored:"id"`
+		Category string  `stored:"category"`
+		Lat      float64 `stored:"lat"`
+		Long     float64 `stored:"long"`
+	}
+	g := dir.Object("geo_partition", place{})
+	g.Primary("id")
+	indexGeo := g.IndexGeo("lat", "long", 7).Partition("category").Counted()
+	dbGeo := g.Done()
+	dbGeo.Clear()
+
+	places := []place{
+		{ID: 1, Category: "restaurant", Lat: 55.7558, Long: 37.6173},
+		{ID: 2, Category: "cafe", Lat: 55.7559, Long: 37.6174},
+		{ID: 3, Category: "restaurant", Lat: 55.7600, Long: 37.6200},
+	}
+	for k := range places {
+		err := dbGeo.Set(&places[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	ids := func(category string) ([]int, error) {
+		rows := []place{}
+		err := indexGeo.Query(55.7560, 37.6175).Radius(1000).Where(category).ScanAll(&rows)
+		res := []int{}
+		for _, row := range rows {
+			res = append(res, row.ID)
+		}
+		return res, err
+	}
+	found, err := ids("restaurant")
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[1 3]" {
+		return fmt.Errorf("incorrect restaurants %v", found)
+	}
+	_, err = indexGeo.Query(55.7560, 37.6175).Hits()
+	if err != ErrPartitionRequired {
+		return fmt.Errorf("partition should be required, got %v", err)
+	}
+	rows := []place{}
+	err = indexGeo.GetGeo(55.7558, 37.6173, "restaurant").ScanAll(&rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 || rows[0].ID != 1 {
+		return fmt.Errorf("incorrect partitioned GetGeo %v", rows)
+	}
+
+	places[0].Category = "cafe" // move between partitions
+	err = dbGeo.Set(&places[0]).Err()
+	if err != nil {
+		return err
+	}
+	found, err = ids("restaurant")
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[3]" {
+		return fmt.Errorf("incorrect restaurants after move %v", found)
+	}
+	found, err = ids("cafe")
+	if err != nil {
+		return err
+	}
+	if len(found) != 2 {
+		return fmt.Errorf("incorrect cafes after move %v", found)
+	}
+	box := GeoBox{MinLat: 55.70, MinLong: 37.50, MaxLat: 55.80, MaxLong: 37.70}
+	facets, err := indexGeo.Clusters(box, 4, "cafe").Facets()
+	if err != nil {
+		return err
+	}
+	if len(facets) != 1 || facets[0].Count != 2 {
+		return fmt.Errorf("incorrect cafe clusters %v", facets)
+	}
+	return nil
+}
+
+func testsVectorIndex(dir *Directory) error {
+	type doc struct {
+		ID        int       `stored:"id"`
+		Embedding []float32 `stored:"embedding"`
+	}
+	d := dir.Object("vector_index", doc{})
+	d.Primary("id")
+	indexVector := d.IndexVector("embedding", 3, VectorCosine).Lists(2)
+	dbDoc := d.Done()
+	dbDoc.Clear()
+
+	docs := []doc{
+		{ID: 1, Embedding: []float32{1, 0, 0}},
+		{ID: 2, Embedding: []float32{0, 1, 0}},
+		{ID: 3, Embedding: []float32{0.9, 0.1, 0}},
+		{ID: 4, Embedding: []float32{0.1, 0.9, 0}},
+	}
+	for k := range docs {
+		err := dbDoc.Set(&docs[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	ids := func(query *VectorQuery) ([]int, error) {
+		rows := []doc{}
+		err := query.ScanAll(&rows)
+		res := []int{}
+		for _, row := range rows {
+			res = append(res, row.ID)
+		}
+		return res, err
+	}
+	query := []float32{1, 0.05, 0}
+	found, err := ids(indexVector.Nearest(query, 2).Probes(1))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[1 3]" {
+		return fmt.Errorf("incorrect approximate nearest %v", found)
+	}
+	hits, err := indexVector.Nearest(query, 2).Exact().Hits()
+	if err != nil {
+		return err
+	}
+	if len(hits) != 2 || hits[0].Primary[0] != int64(1) || hits[0].Score < hits[1].Score {
+		return fmt.Errorf("incorrect exact nearest %v", hits)
+	}
+
+	docs[2].Embedding = []float32{0, 1, 0.1}
+	err = dbDoc.Set(&docs[2]).Err()
+	if err != nil {
+		return err
+	}
+	found, err = ids(indexVector.Nearest(query, 2).Exact())
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[1 4]" {
+		return fmt.Errorf("incorrect nearest after update %v", found)
+	}
+	err = dbDoc.Delete(&docs[0]).Err()
+	if err != nil {
+		return err
+	}
+	found, err = ids(indexVector.Nearest(query, 1))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[4]" {
+		return fmt.Errorf("incorrect nearest after delete %v", found)
+	}
+	_, err = indexVector.Nearest([]float32{1, 0}, 1).Hits()
+	if err == nil {
+		return errors.New("vector of wrong dimensions should fail")
+	}
+
+	docs = append(docs, doc{ID: 5, Embedding: []float32{0.95, 0, 0.05}}, doc{ID: 6, Embedding: []float32{0, 0, 1}})
+	for k := 4; k < len(docs); k++ {
+		err = dbDoc.Set(&docs[k]).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err = indexVector.Train(5)
+	if err != nil {
+		return err
+	}
+	found, err = ids(indexVector.Nearest([]float32{0, 0, 1}, 1).Probes(1))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[6]" {
+		return fmt.Errorf("incorrect nearest after train %v", found)
+	}
+	err = dbDoc.Set(&doc{ID: 7, Embedding: []float32{0, 0.1, 1}}).Err()
+	if err != nil {
+		return err
+	}
+	found, err = ids(indexVector.Nearest([]float32{0, 0, 1}, 2).Probes(1))
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(found) != "[6 7]" {
+		return fmt.Errorf("incorrect nearest written after train %v", found)
+	}
+	return nil
+}
+
+func testsContext(dir *Directory) error {
 	type row struct {
-		ID int `stored:"id"`
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	r := dir.Object("context_test", row{})
+	r.Primary("id")
+	dbRow := r.Done()
+	dbRow.Clear()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := dbRow.Set(&row{ID: 1, Name: "first"}).ErrCtx(ctx)
+	if err != nil {
+		return err
+	}
+	rows := []row{}
+	err = dbRow.List().ScanAllCtx(ctx, &rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("incorrect rows count %d instead of 1", len(rows))
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	err = dbRow.Set(&row{ID: 2, Name: "second"}).ErrCtx(cancelled)
+	if err != context.Canceled {
+		return fmt.Errorf("cancelled write should return context error, got %v", err)
+	}
+	err = dir.WriteCtx(cancelled, func(tr *Transaction) {
+		dbRow.Set(&row{ID: 3, Name: "third"}).Check(tr)
+	}).Err()
+	if err != context.Canceled {
+		return fmt.Errorf("cancelled transaction should return context error, got %v", err)
+	}
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	err = dbRow.List().ScanAllCtx(expired, &rows)
+	if err != context.DeadlineExceeded {
+		return fmt.Errorf("expired read should return deadline error, got %v", err)
+	}
+	count, err := dbRow.List().Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 1 {
+		return fmt.Errorf("cancelled writes should not be committed, %d objects found", count)
+	}
+	return nil
+}
+
+func testsTxOptions(dir *Directory) error {
+	type row struct {
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	r := dir.Object("tx_options", row{})
+	r.Primary("id")
+	dbRow := r.Done()
+	dbRow.Clear()
+
+	options := TxOptions{
+		RetryLimit: 3,
+		Timeout:    5 * time.Second,
+		Priority:   TxPriorityBatch,
+		Snapshot:   true,
+	}
+	err := dbRow.Set(&row{ID: 1, Name: "first"}).Options(options).Err()
+	if err != nil {
+		return err
+	}
+	rows := []row{}
+	err = dbRow.List().Promise().Options(options).ScanAll(&rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("incorrect rows count %d instead of 1", len(rows))
+	}
+	err = dbRow.Add(&row{ID: 1}).Options(options).Err()
+	if !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("logical error expected, got %v", err)
+	}
+
+	attempts := 0
+	backoffs := 0
+	err = dir.WriteWith(TxOptions{
+		RetryLimit: 2,
+		Backoff: func(attempt int, err error) time.Duration {
+			backoffs++
+			return time.Millisecond
+		},
+	}, func(tr *Transaction) {
+		attempts++
+		existing := row{ID: 1}
+		dbRow.Get(&existing).Submit(tr, func(err error) error {
+			// conflicting write after the read, so commit will fail each time
+			return dbRow.Set(&row{ID: 1, Name: strconv.Itoa(attempts)}).Err()
+		})
+		dbRow.Set(&row{ID: 2, Name: "second"}).Check(tr)
+	}).Err()
+	retryErr, ok := err.(*RetryError)
+	if !ok {
+		return fmt.Errorf("retry error expected, got %v", err)
+	}
+	if retryErr.Attempts != 3 || attempts != 3 || backoffs != 2 {
+		return fmt.Errorf("incorrect attempts %d (%d callbacks, %d backoffs)", retryErr.Attempts, attempts, backoffs)
+	}
+	return nil
+}
+
+func testsTransactionRetry(dir *Directory) error {
+	type row struct {
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	r := dir.Object("transaction_retry", row{})
+	r.Primary("id")
+	dbRow := r.Done()
+	dbRow.Clear()
+
+	err := dbRow.Set(&row{ID: 1, Name: "first"}).Err()
+	if err != nil {
+		return err
+	}
+	attempts := 0
+	err = dir.Write(func(tr *Transaction) {
+		attempts++
+		if attempts == 1 {
+			existing := row{ID: 1}
+			dbRow.Get(&existing).Check(tr)
+			err := tr.Err() // promises of the first attempt are performed before the conflict
+			if err != nil {
+				return
+			}
+			dbRow.Set(&row{ID: 1, Name: "conflict"}).Err() // conflicting write, so commit will fail
+		}
+		dbRow.Set(&row{ID: 2, Name: "retried"}).Check(tr)
+	}).Err()
+	if err != nil {
+		return err
+	}
+	if attempts != 2 {
+		return fmt.Errorf("transaction should be retried once, %d attempts", attempts)
+	}
+	fetched := row{ID: 2}
+	err = dbRow.Get(&fetched).Err()
+	if err != nil {
+		return fmt.Errorf("promises of the retry should be performed: %v", err)
+	}
+	if fetched.Name != "retried" {
+		return fmt.Errorf("incorrect object written by retry: %v", fetched)
+	}
+	return nil
+}
+
+func testsCommitHooks(dir *Directory) error {
+	type row struct {
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	r := dir.Object("commit_hooks", row{})
+	r.Primary("id")
+	dbRow := r.Done()
+	dbRow.Clear()
+
+	committed := 0
+	failed := []error{}
+	err := dbRow.Set(&row{ID: 1, Name: "first"}).OnCommit(func() {
+		committed++
+	}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbRow.Add(&row{ID: 1}).OnCommit(func() {
+		committed++
+	}).OnFailure(func(err error) {
+		failed = append(failed, err)
+	}).Err()
+	if committed != 1 || len(failed) != 1 || !errors.Is(failed[0], ErrAlreadyExist) {
+		return fmt.Errorf("incorrect promise hooks: %d commits, failures %v", committed, failed)
+	}
+
+	conflict := func(tr *Transaction, attempts int) {
+		existing := row{ID: 1}
+		dbRow.Get(&existing).Submit(tr, func(err error) error {
+			// conflicting write after the read, so commit will fail
+			return dbRow.Set(&row{ID: 1, Name: strconv.Itoa(attempts)}).Err()
+		})
+	}
+	attempts := 0
+	committed = 0
+	promiseCommitted := 0
+	err = dir.Write(func(tr *Transaction) {
+		attempts++
+		if attempts == 1 {
+			conflict(tr, attempts)
+		}
+		tr.OnCommit(func() {
+			committed++
+		})
+		dbRow.Set(&row{ID: 2, Name: "second"}).OnCommit(func() {
+			promiseCommitted++
+		}).Check(tr)
+	}).Err()
+	if err != nil {
+		return err
+	}
+	if attempts != 2 || committed != 1 || promiseCommitted != 1 {
+		return fmt.Errorf("hooks should be called once: %d attempts, %d commits, %d promise commits", attempts, committed, promiseCommitted)
+	}
+
+	attempts = 0
+	committed = 0
+	failed = []error{}
+	err = dir.WriteWith(TxOptions{RetryLimit: 1}, func(tr *Transaction) {
+		attempts++
+		conflict(tr, attempts)
+		tr.OnCommit(func() {
+			committed++
+		})
+		tr.OnFailure(func(err error) {
+			failed = append(failed, err)
+		})
+	}).Err()
+	if err == nil {
+		return errors.New("transaction should fail")
+	}
+	if attempts != 2 || committed != 0 || len(failed) != 1 || failed[0] != err {
+		return fmt.Errorf("failure hook should be called once: %d attempts, %d commits, failures %v", attempts, committed, failed)
+	}
+	return nil
+}
+
+func testsIdempotency(dir *Directory) error {
+	type row struct {
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	type group struct {
+		ID int `stored:"id"`
+	}
+	r := dir.Object("idempotency_row", row{})
+	r.AutoIncrement("id")
+	r.Primary("id")
+	dbRow := r.Done()
+	g := dir.Object("idempotency_group", group{})
+	g.Primary("id")
+	dbGroup := g.Done()
+	rowGroup := r.N2N(g, "")
+	dbRow.Clear()
+	dbGroup.Clear()
+	rowGroup.Clear()
+
+	run := strconv.FormatInt(time.Now().UnixNano(), 10) // markers outlive cleared objects
+	first := row{Name: "first"}
+	err := dbRow.Add(&first).Idempotent("add" + run).Err()
+	if err != nil {
+		return err
+	}
+	repeated := row{Name: "first"}
+	err = dbRow.Add(&repeated).Idempotent("add" + run).Err()
+	if err != nil {
+		return err
+	}
+	if repeated.ID != first.ID {
+		return fmt.Errorf("repeated add should return id %d instead of %d", first.ID, repeated.ID)
+	}
+	count, err := dbRow.ListAll().Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 1 {
+		return fmt.Errorf("repeated add should be skipped, %d objects added", count)
+	}
+
+	err = dir.Write(func(tr *Transaction) {
+		dbRow.Set(&row{ID: first.ID, Name: "second"}).Idempotent("set" + run).Check(tr)
+	}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbRow.Set(&row{ID: first.ID, Name: "third"}).Idempotent("set" + run).Err()
+	if err != nil {
+		return err
+	}
+	fetched := row{ID: first.ID}
+	err = dbRow.Get(&fetched).Err()
+	if err != nil {
+		return err
+	}
+	if fetched.Name != "second" {
+		return fmt.Errorf("repeated set should be skipped, name is %s", fetched.Name)
+	}
+
+	err = dbGroup.Set(&group{ID: 2}).Idempotent("add" + run).Err() // keys are scoped by object
+	if err != nil {
+		return err
+	}
+	err = dbGroup.Get(&group{ID: 2}).Err()
+	if err != nil {
+		return fmt.Errorf("same key of another object should not skip the write: %v", err)
+	}
+
+	err = dbGroup.Set(&group{ID: 1}).Err()
+	if err != nil {
+		return err
+	}
+	err = rowGroup.Add(&first, &group{ID: 1}).Idempotent("relation" + run).Err()
+	if err != nil {
+		return err
+	}
+	err = rowGroup.Delete(&first, &group{ID: 1}).Err()
+	if err != nil {
+		return err
+	}
+	err = rowGroup.Add(&first, &group{ID: 1}).Idempotent("relation" + run).Err()
+	if err != nil {
+		return err
+	}
+	related, err := rowGroup.Check(&first, &group{ID: 1}).Bool()
+	if err != nil {
+		return err
+	}
+	if related {
+		return errors.New("repeated relation add should be skipped")
+	}
+
+	calls := 0
+	for k := 0; k < 2; k++ {
+		err = dir.WriteIdempotent("write"+run, func(tr *Transaction) {
+			calls++
+			dbRow.Add(&row{Name: "written"}).Check(tr)
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	if calls != 1 {
+		return fmt.Errorf("repeated write should be skipped, callback called %d times", calls)
+	}
+
+	dir.SetIdempotencyWindow(time.Millisecond)
+	defer dir.SetIdempotencyWindow(0)
+	err = dbRow.Add(&row{Name: "expiring"}).Idempotent("expire" + run).Err()
+	if err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	expired := row{Name: "expiring"}
+	err = dbRow.Add(&expired).Idempotent("expire" + run).Err()
+	if err != nil {
+		return err
+	}
+	count, err = dbRow.ListAll().Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 4 {
+		return fmt.Errorf("add should be performed once marker expired, %d objects added", count)
+	}
+	return nil
+}
+
+func testsTyped(dir *Directory) error {
+	type member struct {
+		ID    int64  `stored:"id"`
+		Login string `stored:"login"`
+	}
+	type team struct {
+		ID   int64  `stored:"id"`
+		Name string `stored:"name"`
+	}
+	m := dir.Object("typed_member", member{})
+	m.Primary("id")
+	m.Unique("login")
+	t := dir.Object("typed_team", team{})
+	t.Primary("id")
+	memberTeam := m.N2N(t, "")
+	dbMember := Typed[member](m)
+	dbTeam := Typed[team](t)
+	typedMemberTeam := TypedN2N[member, team](memberTeam)
+	dbMember.Object().Clear()
+	dbTeam.Object().Clear()
+	memberTeam.Clear()
+
+	ctx := context.Background()
+	total := typedPageSize*2 + 10
+	err := dir.Write(func(tr *Transaction) {
+		for k := 1; k <= total; k++ {
+			dbMember.Set(member{ID: int64(k), Login: "login" + strconv.Itoa(k)}).Check(tr)
+		}
+	}).Err()
+	if err != nil {
+		return err
+	}
+	fetched, err := dbMember.Get(ctx, 5) // int is converted to int64 of the field
+	if err != nil {
+		return err
+	}
+	if fetched.Login != "login5" {
+		return fmt.Errorf("incorrect typed get: %+v", fetched)
+	}
+	_, err = dbMember.Get(ctx, "5")
+	if err == nil {
+		return errors.New("get with value of wrong type should fail")
+	}
+	_, err = dbMember.Get(ctx, 100500)
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("get of missing object should fail with ErrNotFound, got %v", err)
+	}
+	fetched, err = dbMember.GetBy(ctx, "login", "login7")
+	if err != nil {
+		return err
+	}
+	if fetched.ID != 7 {
+		return fmt.Errorf("incorrect typed get by index: %+v", fetched)
+	}
+
+	expected := int64(1)
+	it := dbMember.List()
+	for it.Next() {
+		if it.Value().ID != expected {
+			return fmt.Errorf("iterator returned %d instead of %d", it.Value().ID, expected)
+		}
+		expected++
+	}
+	if it.Err() != nil {
+		return it.Err()
+	}
+	if expected != int64(total)+1 {
+		return fmt.Errorf("iterator passed %d objects instead of %d", expected-1, total)
+	}
+
+	err = dbTeam.Set(team{ID: 1, Name: "core"}).Err()
+	if err != nil {
+		return err
+	}
+	err = typedMemberTeam.Add(member{ID: 3}, team{ID: 1}).Err()
+	if err != nil {
+		return err
+	}
+	teams, err := typedMemberTeam.GetClients(ctx, member{ID: 3}, 10)
+	if err != nil {
+		return err
+	}
+	if len(teams) != 1 || teams[0].Name != "core" {
+		return fmt.Errorf("incorrect typed relation clients: %+v", teams)
+	}
+	related, err := typedMemberTeam.Check(ctx, member{ID: 4}, team{ID: 1})
+	if err != nil {
+		return err
+	}
+	if related {
+		return errors.New("relation should not be set")
+	}
+	return nil
+}
+
+func testsStructuredErrors(dir *Directory) error {
+	type person struct {
+		ID    int64  `stored:"id"`
+		Email string `stored:"email"`
+	}
+	type club struct {
+		ID int64 `stored:"id"`
+	}
+	p := dir.Object("errors_person", person{})
+	p.Primary("id")
+	p.Unique("email")
+	c := dir.Object("errors_club", club{})
+	c.Primary("id")
+	personClub := p.N2N(c, "")
+	dbPerson := p.Done()
+	dbClub := c.Done()
+	dbPerson.Clear()
+	dbClub.Clear()
+	personClub.Clear()
+
+	err := dbPerson.Set(&person{ID: 1, Email: "a@x.com"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbPerson.Set(&person{ID: 2, Email: "a@x.com"}).Err()
+	if !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("taken email should fail with ErrAlreadyExist, got %v", err)
+	}
+	violation, ok := err.(*UniqueViolation)
+	if !ok {
+		return fmt.Errorf("taken email should fail with *UniqueViolation, got %T", err)
+	}
+	if violation.Object != "errors_person" || violation.Index != "email" || len(violation.ExistingPrimary) != 1 || violation.ExistingPrimary[0] != int64(1) {
+		return fmt.Errorf("incorrect unique violation %+v", violation)
+	}
+	err = dbPerson.Add(&person{ID: 1, Email: "b@x.com"}).Err()
+	if !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("existing primary should fail with ErrAlreadyExist, got %v", err)
+	}
+	duplicate, ok := err.(*DuplicateError)
+	if !ok {
+		return fmt.Errorf("existing primary should fail with *DuplicateError, got %T", err)
+	}
+	if duplicate.Object != "errors_person" || len(duplicate.Primary) != 1 || duplicate.Primary[0] != int64(1) {
+		return fmt.Errorf("incorrect duplicate error %+v", duplicate)
+	}
+
+	err = dbPerson.Get(&person{ID: 3}).Err()
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("missing person should fail with ErrNotFound, got %v", err)
+	}
+	notFound, ok := err.(*NotFoundError)
+	if !ok {
+		return fmt.Errorf("missing person should fail with *NotFoundError, got %T", err)
+	}
+	if notFound.Object != "errors_person" || len(notFound.Primary) != 1 || notFound.Primary[0] != int64(3) {
+		return fmt.Errorf("incorrect not found error %+v", notFound)
+	}
+	err = personClub.FillClientData(&person{ID: 1}, &club{ID: 1}).Err()
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("missing relation should fail with ErrNotFound, got %v", err)
+	}
+	err = personClub.Add(&person{ID: 1}, &club{ID: 1}).Err()
+	if err != nil {
+		return err
+	}
+	err = personClub.Add(&person{ID: 1}, &club{ID: 1}).Err()
+	if _, ok := err.(*DuplicateError); !ok || !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("existing relation should fail with *DuplicateError, got %v", err)
+	}
+
+	_, err = dir.Cluster.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+		tr.Set(dbPerson.primary.Pack(tuple.Tuple{int64(4), "email", "nested"}), []byte{})
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	persons := []person{}
+	err = dbPerson.ListAll().ScanAll(&persons)
+	if !errors.Is(err, ErrDataCorrupt) {
+		return fmt.Errorf("corrupted object should fail with ErrDataCorrupt, got %v", err)
+	}
+	if _, ok := err.(*CorruptionError); !ok {
+		return fmt.Errorf("corrupted object should fail with *CorruptionError, got %T", err)
+	}
+	return nil
+}
+
+func testsFutures(dir *Directory) error {
+	type author struct {
+		ID   int64  `stored:"id"`
+		Name string `stored:"name"`
+	}
+	type book struct {
+		ID       int64  `stored:"id"`
+		AuthorID int64  `stored:"author_id"`
+		Title    string `stored:"title"`
+	}
+	a := dir.Object("futures_author", author{})
+	a.Primary("id")
+	b := dir.Object("futures_book", book{})
+	b.Primary("id")
+	b.Index("author_id")
+	dbAuthor := Typed[author](a)
+	dbBook := Typed[book](b)
+	dbAuthor.Object().Clear()
+	dbBook.Object().Clear()
+
+	err := All(
+		dbAuthor.Set(author{ID: 1, Name: "Tolstoy"}),
+		dbAuthor.Set(author{ID: 2, Name: "Chekhov"}),
+		dbBook.Set(book{ID: 1, AuthorID: 1, Title: "War and Peace"}),
+		dbBook.Set(book{ID: 2, AuthorID: 1, Title: "Anna Karenina"}),
+	).Err()
+	if err != nil {
+		return err
+	}
+
+	first := dbAuthor.GetFuture(1)
+	second := dbAuthor.GetFuture(2)
+	books := FutureSlice[book](dbBook.Object().Use("author_id").List(int64(1)).Promise())
+	err = All(first, second, books).Err()
+	if err != nil {
+		return err
+	}
+	firstAuthor, err := first.Wait()
+	if err != nil {
+		return err
+	}
+	secondAuthor, err := second.Wait()
+	if err != nil {
+		return err
+	}
+	authorBooks, err := books.Wait()
+	if err != nil {
+		return err
+	}
+	if firstAuthor.Name != "Tolstoy" || secondAuthor.Name != "Chekhov" || len(authorBooks) != 2 {
+		return fmt.Errorf("incorrect futures values: %+v, %+v, %d books", firstAuthor, secondAuthor, len(authorBooks))
+	}
+
+	title := Map(dbBook.GetFuture(2), func(value book) (string, error) {
+		return value.Title, nil
+	})
+	result := <-title.Async()
+	if result.Err != nil {
+		return result.Err
+	}
+	if result.Value != "Anna Karenina" {
+		return fmt.Errorf("incorrect mapped value %s", result.Value)
+	}
+
+	renamed := dbAuthor.GetFuture(2).Then(func(value author) PromiseAny {
+		value.Name += " A."
+		return dbAuthor.Set(value)
+	})
+	_, err = renamed.Wait()
+	if err != nil {
+		return err
+	}
+	secondAuthor, err = dbAuthor.Get(context.Background(), 2)
+	if err != nil {
+		return err
+	}
+	if secondAuthor.Name != "Chekhov A." {
+		return fmt.Errorf("then promise was not performed, name %s", secondAuthor.Name)
+	}
+
+	missing := dbAuthor.GetFuture(3)
+	found := dbAuthor.GetFuture(1)
+	index, err := Any(missing, found).Wait()
+	if err != nil {
+		return err
+	}
+	if index != 1 {
+		return fmt.Errorf("any should be resolved by second future, got %d", index)
+	}
+	err = All(dbAuthor.GetFuture(3), dbAuthor.GetFuture(1)).Err()
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("all should fail with ErrNotFound, got %v", err)
+	}
+	err = All().Err()
+	if err != nil {
+		return fmt.Errorf("all of no promises should be resolved, got %v", err)
+	}
+	index, err = Any().Wait()
+	if !errors.Is(err, ErrNoPromises) {
+		return fmt.Errorf("any of no promises should fail with ErrNoPromises, got %d %v", index, err)
+	}
+	index, err = Any(dbAuthor.GetFuture(1), dbAuthor.Set(author{ID: 3, Name: "Gogol"})).Wait()
+	if !errors.Is(err, ErrAnyWrite) {
+		return fmt.Errorf("any of write promises should fail with ErrAnyWrite, got %d %v", index, err)
+	}
+	_, err = dbAuthor.Get(context.Background(), 3)
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("write promise of failed any should not be performed, got %v", err)
+	}
+	err = dir.Read(func(tr *Transaction) {
+		All().Check(tr)
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("all of no promises inside transaction should be resolved, got %v", err)
+	}
+	return nil
+}
+
+func testsLoader(dir *Directory) error {
+	type account struct {
+		ID    int64  `stored:"id"`
+		Email string `stored:"email"`
+	}
+	a := dir.Object("loader_account", account{})
+	a.Primary("id")
+	a.Unique("email")
+	dbAccount := a.Done()
+	dbAccount.Clear()
+
+	for i := int64(1); i <= 5; i++ {
+		err := dbAccount.Set(&account{ID: i, Email: "user" + strconv.FormatInt(i, 10) + "@x.com"}).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	loader := dbAccount.Loader(5*time.Millisecond, 0)
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := int64(1); i <= 10; i++ {
+		id := (i-1)%5 + 1 // every account requested twice
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			item := account{ID: id}
+			err := loader.Get(&item)
+			if err == nil && item.Email != "user"+strconv.FormatInt(id, 10)+"@x.com" {
+				err = fmt.Errorf("loader fetched incorrect account %+v", item)
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			item := account{Email: "user" + strconv.FormatInt(id, 10) + "@x.com"}
+			err := loader.GetBy(&item, "email")
+			if err == nil && item.ID != id {
+				err = fmt.Errorf("loader fetched incorrect account by email %+v", item)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	err := loader.Get(&account{ID: 6})
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("missing account should fail with ErrNotFound, got %v", err)
+	}
+	err = loader.GetBy(&account{Email: "missing@x.com"}, "email")
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("missing email should fail with ErrNotFound, got %v", err)
+	}
+
+	small := dbAccount.Loader(time.Hour, 2) // batch is fetched once full, not by timer
+	errs = make(chan error, 2)
+	for i := int64(1); i <= 2; i++ {
+		id := i
+		go func() {
+			errs <- small.Get(&account{ID: id})
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+		case <-time.After(5 * time.Second):
+			return errors.New("full batch was not fetched")
+		}
+	}
+	return nil
+}
+
+func testsSingleField(dir *Directory) error {
+	type row struct {
+		ID int `stored:"id"`
+	}
+	r := dir.Object("single_field", row{})
+	r.Primary("id")
+	dbRow := r.Done()
+	dbRow.Clear()
+
+	item := row{
+		ID: 25,
+	}
+	err := dbRow.Set(&item).Err()
+	if err != nil {
+		return err
+	}
+
+	items := []row{}
+	err = dbRow.List().ScanAll(&items)
+	if err != nil {
+		return err
+	}
+	if len(items) != 1 {
+		return fmt.Errorf("items len is incorrrect should be 1, but %d", len(items))
+	}
+	if items[0].ID != 25 {
+		return fmt.Errorf("item id is incorrrect should be 25, but %d", items[0].ID)
+	}
+	return nil
+
+}
+
+func testsIndexCount(dir *Directory) error {
+	type item struct {
+		ID       int    `stored:"id"`
+		Category string `stored:"category"`
+		Color    string `stored:"color"`
+	}
+	i := dir.Object("count_item", item{})
+	i.Primary("id")
+	i.Index("color")
+	i.IndexCounted("category", "color")
+	dbItem := i.Done()
+	dbItem.Clear()
+
+	items := []item{
+		{ID: 1, Category: "shoes", Color: "red"},
+		{ID: 2, Category: "shoes", Color: "red"},
+		{ID: 3, Category: "shoes", Color: "blue"},
+		{ID: 4, Category: "hats", Color: "red"},
+	}
+	for _, it := range items {
+		err := dbItem.Set(it).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err := dbItem.Delete(4).Err()
+	if err != nil {
+		return err
+	}
+
+	count, err := dbItem.ListAll().Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 3 {
+		return fmt.Errorf("primary count is %d instead of 3", count)
+	}
+	count, err = dbItem.Use("color").List("red").Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 2 {
+		return fmt.Errorf("index count is %d instead of 2", count)
+	}
+	count, err = dbItem.Use("category", "color").List("shoes").Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != 3 {
+		return fmt.Errorf("counted index count is %d instead of 3", count)
+	}
+
+	facets, err := dbItem.Index("category", "color").Distinct("shoes").Facets()
+	if err != nil {
+		return err
+	}
+	if len(facets) != 2 || facets[0].Value != "blue" || facets[0].Count != 1 || facets[1].Count != 2 {
+		return fmt.Errorf("counted distinct is incorrect: %v", facets)
+	}
+	facets, err = dbItem.Index("color").Distinct().Facets()
+	if err != nil {
+		return err
+	}
+	if len(facets) != 2 || facets[1].Value != "red" || facets[1].Count != 2 {
+		return fmt.Errorf("distinct is incorrect: %v", facets)
+	}
+	facets, err = dbItem.Index("category", "color").Distinct().Facets() // last hat was deleted
+	if err != nil {
+		return err
+	}
+	if len(facets) != 1 || facets[0].Value != "shoes" || facets[0].Count != 3 {
+		return fmt.Errorf("counted distinct returned removed values: %v", facets)
+	}
+
+	err = dir.Write(func(tr *Transaction) { // primary range larger than one page of keys
+		for id := 100; id < 100+queryPageKeys; id++ {
+			dbItem.Set(item{ID: id, Category: "socks", Color: "white"}).Check(tr)
+		}
+	}).Err()
+	if err != nil {
+		return err
+	}
+	count, err = dbItem.ListAll().Count().Int64()
+	if err != nil {
+		return err
+	}
+	if count != int64(3+queryPageKeys) {
+		return fmt.Errorf("paged primary count is %d instead of %d", count, 3+queryPageKeys)
+	}
+	return nil
+}
+
+func testsCollation(dir *Directory) error {
+	type account struct {
+		ID      int    `stored:"id"`
+		Email   string `stored:"email,nocase"`
+		Created int64  `stored:"created,desc"`
+	}
+	a := dir.Object("collation_account", account{})
+	a.Primary("id")
+	a.Unique("email")
+	a.Index("created")
+	dbAccount := a.Done()
+	dbAccount.Clear()
+
+	err := dbAccount.Set(account{ID: 1, Email: "John@x.com", Created: 10}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbAccount.Set(account{ID: 2, Email: "john@x.com", Created: 20}).Err()
+	if !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("case insensitive unique should fail with ErrAlreadyExist, got %v", err)
+	}
+	err = dbAccount.Set(account{ID: 2, Email: "sam@x.com", Created: 20}).Err()
+	if err != nil {
+		return err
+	}
+	got := account{Email: "JOHN@X.COM"}
+	err = dbAccount.GetBy(&got, "email").Err()
+	if err != nil {
+		return err
+	}
+	if got.ID != 1 {
+		return fmt.Errorf("GetBy fetched id %d instead of 1", got.ID)
+	}
+
+	accounts := []account{}
+	err = dbAccount.Use("created").ScanAll(&accounts)
+	if err != nil {
+		return err
+	}
+	if len(accounts) != 2 || accounts[0].ID != 2 || accounts[1].ID != 1 {
+		return fmt.Errorf("descending index returned incorrect order: %v", accounts)
+	}
+	accounts = []account{}
+	err = dbAccount.Use("created").From(int64(15)).ScanAll(&accounts)
+	if err != nil {
+		return err
+	}
+	if len(accounts) != 1 || accounts[0].ID != 1 {
+		return fmt.Errorf("descending index From returned incorrect rows: %v", accounts)
+	}
+	return nil
+}
+
+func testsUniqueNamespace(dir *Directory) error {
+	type bot struct {
+		ID    int    `stored:"id"`
+		Login string `stored:"login"`
+	}
+	login := dir.UniqueNamespace("login")
+	u := dir.Object("namespace_user", user{})
+	u.UniqueIn(login, "login")
+	b := dir.Object("namespace_bot", bot{})
+	b.Primary("id")
+	b.UniqueIn(login, "login")
+	dbUser := u.Done()
+	dbBot := b.Done()
+	dbUser.Clear()
+	dbBot.Clear()
+
+	err := dbUser.Set(user{ID: 1, Login: "john"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbBot.Set(bot{ID: 5, Login: "john"}).Err()
+	if !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("bot with taken login should fail with ErrAlreadyExist, got %v", err)
+	}
+	violation, ok := err.(*UniqueViolation)
+	if !ok || violation.Object != "namespace_user" || violation.ExistingPrimary[0] != int64(1) {
+		return fmt.Errorf("violation should point to the user: %v", err)
+	}
+	err = dbUser.Set(user{ID: 1, Login: "sam"}).Err() // releases login
+	if err != nil {
+		return err
+	}
+	err = dbBot.Set(bot{ID: 5, Login: "john"}).Err()
+	if err != nil {
+		return err
+	}
+	got := bot{Login: "john"}
+	err = dbBot.GetBy(&got, "login").Err()
+	if err != nil {
+		return err
+	}
+	if got.ID != 5 {
+		return fmt.Errorf("GetBy fetched bot %d instead of 5", got.ID)
+	}
+	gotUser := user{Login: "john"}
+	err = dbUser.GetBy(&gotUser, "login").Err()
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("login owned by bot should not be found for user, got %v", err)
+	}
+
+	for batch := 0; batch < 11; batch++ { // namespace is cleared by several transactions
+		err = dir.Write(func(tr *Transaction) {
+			for k := 0; k < 100; k++ {
+				dbBot.Set(bot{ID: 10 + batch*100 + k, Login: "bot" + strconv.Itoa(batch*100+k)}).Check(tr)
+			}
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err = dbUser.Set(user{ID: 2, Login: "zed"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbUser.Clear()
+	if err != nil {
+		return err
+	}
+	err = dbBot.Set(bot{ID: 6, Login: "zed"}).Err()
+	if err != nil {
+		return fmt.Errorf("login of cleared user should be released: %v", err)
+	}
+	err = dbUser.Set(user{ID: 3, Login: "bot1099"}).Err()
+	if !errors.Is(err, ErrAlreadyExist) {
+		return fmt.Errorf("logins of bots should be kept by clear of users, got %v", err)
+	}
+	return nil
+}
+
+func testsProjection(dir *Directory) error {
+	type profile struct {
+		ID     int    `stored:"id"`
+		ChatID int    `stored:"chat_id"`
+		Name   string `stored:"name"`
+		Avatar string `stored:"avatar"`
+		Bio    string `stored:"bio"`
+	}
+	p := dir.Object("projection_profile", profile{})
+	p.Primary("id")
+	p.IndexCovering([]string{"chat_id"}, "name", "avatar")
+	dbProfile := p.Done()
+	dbProfile.Clear()
+
+	err := dbProfile.Set(profile{ID: 1, ChatID: 7, Name: "John", Avatar: "john.png", Bio: "long text"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbProfile.Set(profile{ID: 1, ChatID: 7, Name: "Johnny", Avatar: "john.png", Bio: "long text"}).Err()
+	if err != nil {
+		return err
+	}
+
+	got := profile{ID: 1}
+	err = dbProfile.GetFields(&got, "name").Err()
+	if err != nil {
+		return err
+	}
+	if got.Name != "Johnny" || got.Bio != "" {
+		return fmt.Errorf("GetFields filled incorrect fields: %v", got)
+	}
+
+	profiles := []profile{}
+	err = dbProfile.Use("chat_id").List(7).Fields("name", "avatar").ScanAll(&profiles)
+	if err != nil {
+		return err
+	}
+	if len(profiles) != 1 || profiles[0].ID != 1 || profiles[0].Name != "Johnny" || profiles[0].Bio != "" {
+		return fmt.Errorf("covering index returned incorrect profiles: %v", profiles)
+	}
+	profiles = []profile{}
+	err = dbProfile.ListAll().Fields("bio").ScanAll(&profiles)
+	if err != nil {
+		return err
+	}
+	if len(profiles) != 1 || profiles[0].Bio != "long text" || profiles[0].Name != "" {
+		return fmt.Errorf("projection returned incorrect profiles: %v", profiles)
+	}
+
+	err = dbProfile.SetField(&profile{ID: 1, Name: "Jo"}, "name").Err() // covered field
+	if err != nil {
+		return err
+	}
+	profiles = []profile{}
+	err = dbProfile.Use("chat_id").List(7).Fields("name", "avatar").ScanAll(&profiles)
+	if err != nil {
+		return err
+	}
+	if len(profiles) != 1 || profiles[0].Name != "Jo" || profiles[0].Avatar != "john.png" {
+		return fmt.Errorf("covering index not updated by SetField: %v", profiles)
+	}
+
+	err = dbProfile.Set(profile{ID: 2, ChatID: 7, Name: "Mary", Bio: "short"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbProfile.Set(profile{ID: 3, ChatID: 8, Name: "Peter"}).Err()
+	if err != nil {
+		return err
+	}
+	profiles = []profile{}
+	err = dbProfile.ListAll().Reverse().Limit(2).Fields("bio").ScanAll(&profiles)
+	if err != nil {
+		return err
+	}
+	if len(profiles) != 2 || profiles[0].ID != 3 || profiles[0].Bio != "" || profiles[1].ID != 2 || profiles[1].Bio != "short" || profiles[1].Name != "" {
+		return fmt.Errorf("reverse projection returned incorrect profiles: %v", profiles)
+	}
+	profiles = []profile{}
+	err = dbProfile.ListAll().Limit(2).Fields("name").ScanAll(&profiles) // objects span several pages
+	if err != nil {
+		return err
+	}
+	if len(profiles) != 2 || profiles[0].Name != "Jo" || profiles[1].Name != "Mary" || profiles[1].Bio != "" {
+		return fmt.Errorf("paged projection returned incorrect profiles: %v", profiles)
+	}
+	return nil
+}
+
+func testsVersionstampIndex(dir *Directory) error {
+	type post struct {
+		ID     int    `stored:"id"`
+		ChatID int    `stored:"chat_id"`
+		Text   string `stored:"text"`
+	}
+	p := dir.Object("versionstamp_post", post{})
+	p.Primary("id")
+	created := p.IndexVersionstamp("created", "chat_id")
+	dbPost := p.Done()
+	dbPost.Clear()
+
+	for _, id := range []int{3, 1, 2, 4} { // commit order differs from primary order
+		err := dbPost.Set(post{ID: id, ChatID: 1, Text: "hello"}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err := dbPost.Set(post{ID: 3, ChatID: 1, Text: "edited"}).Err() // update keeps position
+	if err != nil {
+		return err
+	}
+	err = dbPost.Delete(4).Err()
+	if err != nil {
+		return err
+	}
+
+	posts := []post{}
+	err = dbPost.Use("created").List(1).ScanAll(&posts)
+	if err != nil {
+		return err
+	}
+	if len(posts) != 3 || posts[0].ID != 3 || posts[1].ID != 1 || posts[2].ID != 2 {
+		return fmt.Errorf("versionstamp order is incorrect: %v", posts)
+	}
+	if posts[0].Text != "edited" {
+		return errors.New("updated post not fetched")
+	}
+
+	cursor, err := created.Cursor(1).Versionstamp()
+	if err != nil {
+		return err
+	}
+	posts = []post{}
+	err = dbPost.Use("created").List(1).From(cursor).ScanAll(&posts)
+	if err != nil {
+		return err
+	}
+	if len(posts) != 2 || posts[0].ID != 1 || posts[1].ID != 2 {
+		return fmt.Errorf("versionstamp cursor scan is incorrect: %v", posts)
+	}
+
+	err = dbPost.Set(post{ID: 2, ChatID: 2, Text: "moved"}).Err() // prefix change moves object
+	if err != nil {
+		return err
+	}
+	posts = []post{}
+	err = dbPost.Use("created").List(2).ScanAll(&posts)
+	if err != nil {
+		return err
+	}
+	if len(posts) != 1 || posts[0].ID != 2 {
+		return fmt.Errorf("moved post not found: %v", posts)
+	}
+
+	err = dir.Write(func(tr *Transaction) { // object written twice inside one transaction
+		added := post{ID: 5, ChatID: 1, Text: "draft"}
+		dbPost.Add(&added).Check(tr)
+		added.Text = "published"
+		dbPost.SetField(&added, "text").Check(tr)
+	}).Err()
+	if err != nil {
+		return err
+	}
+	posts = []post{}
+	err = dbPost.Use("created").List(1).ScanAll(&posts)
+	if err != nil {
+		return err
+	}
+	if len(posts) != 3 || posts[2].ID != 5 || posts[2].Text != "published" {
+		return fmt.Errorf("post written twice in transaction is incorrect: %v", posts)
+	}
+	err = dir.Write(func(tr *Transaction) {
+		dbPost.Add(&post{ID: 6, ChatID: 1}).Check(tr)
+		dbPost.Set(&post{ID: 6, ChatID: 2}).Check(tr)
+	}).Err()
+	if !errors.Is(err, ErrVersionstampPending) {
+		return fmt.Errorf("moving post added in the same transaction should fail: %v", err)
+	}
+	return nil
+}
+
+func testsSearchAnalyzer(dir *Directory) error {
+	type article struct {
+		ID    int    `stored:"id"`
+		Title string `stored:"title"`
+	}
+	a := dir.Object("search_analyzer_article", article{})
+	a.Primary("id")
+	title := a.IndexSearch("title", IndexOption{Analyzer: AnalyzerEnglish})
+	dbArticle := a.Done()
+	dbArticle.Clear()
+
+	err := dbArticle.Set(article{ID: 1, Title: "Running the Café—quickly!"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbArticle.Set(article{ID: 2, Title: "Cats are sleeping"}).Err()
+	if err != nil {
+		return err
+	}
+
+	articles := []article{}
+	err = title.Search("runs cafe").ScanAll(&articles)
+	if err != nil {
+		return err
+	}
+	if len(articles) != 1 || articles[0].ID != 1 {
+		return fmt.Errorf("stemmed search is incorrect: %v", articles)
+	}
+	articles = []article{}
+	err = title.Search("the cat").ScanAll(&articles)
+	if err != nil {
+		return err
+	}
+	if len(articles) != 1 || articles[0].ID != 2 {
+		return fmt.Errorf("stop words search is incorrect: %v", articles)
+	}
+	return nil
+}
+
+func testsSearchRanking(dir *Directory) error {
+	type article struct {
+		ID    int    `stored:"id"`
+		Title string `stored:"title"`
+	}
+	a := dir.Object("search_ranking_article", article{})
+	a.Primary("id")
+	title := a.IndexSearch("title", IndexOption{Analyzer: AnalyzerEnglish})
+	dbArticle := a.Done()
+	dbArticle.Clear()
+
+	articles := []article{
+		{ID: 1, Title: "database news and weather report for the whole long week"},
+		{ID: 2, Title: "database database database"},
+		{ID: 3, Title: "weather today"},
+	}
+	for _, it := range articles {
+		err := dbArticle.Set(it).Err()
+		if err != nil {
+			return err
+		}
 	}
-	r := dir.Object("single_field", row{})
-	r.Primary("id")
-	dbRow := r.Done()
-	dbRow.Clear()
 
-	item := row{
-		ID: 25,
+	hits, err := title.Query("database").Hits()
+	if err != nil {
+		return err
 	}
-	err := dbRow.Set(&item).Err()
+	if len(hits) != 2 || hits[0].Score <= hits[1].Score {
+		return fmt.Errorf("ranked hits incorrect: %v", hits)
+	}
+	first := article{}
+	err = hits[0].Value.Scan(&first)
 	if err != nil {
 		return err
 	}
+	if first.ID != 2 {
+		return fmt.Errorf("best hit should be 2, got %d", first.ID)
+	}
 
-	items := []row{}
-	err = dbRow.List().ScanAll(&items)
+	found := []article{}
+	err = title.Query("database weather").AnyWord().ScanAll(&found)
 	if err != nil {
 		return err
 	}
-	if len(items) != 1 {
-		return fmt.Errorf("items len is incorrrect should be 1, but %d", len(items))
+	if len(found) != 3 || found[0].ID != 1 {
+		return fmt.Errorf("any word search incorrect: %v", found)
 	}
-	if items[0].ID != 25 {
-		return fmt.Errorf("item id is incorrrect should be 25, but %d", items[0].ID)
+
+	err = dbArticle.Delete(2).Err()
+	if err != nil {
+		return err
+	}
+	found = []article{}
+	err = title.Search("database").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 1 {
+		return fmt.Errorf("deleted object still found: %v", found)
+	}
+	return nil
+}
+
+func testsSearchFuzzy(dir *Directory) error {
+	type person struct {
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	pr := dir.Object("search_fuzzy_person", person{})
+	pr.Primary("id")
+	name := pr.IndexSearch("name", IndexOption{Fuzzy: true})
+	dbPerson := pr.Done()
+	dbPerson.Clear()
+
+	people := []person{
+		{ID: 1, Name: "John Smith"},
+		{ID: 2, Name: "Jane Smithson"},
+		{ID: 3, Name: "Peter Parker"},
+	}
+	for _, it := range people {
+		err := dbPerson.Set(it).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	found := []person{}
+	err := name.Search("jhon smth").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 1 {
+		return fmt.Errorf("fuzzy search incorrect: %v", found)
+	}
+
+	err = dbPerson.Set(person{ID: 1, Name: "Mary Watson"}).Err()
+	if err != nil {
+		return err
+	}
+	found = []person{}
+	err = name.Search("jhon smth").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 0 {
+		return fmt.Errorf("trigrams not updated: %v", found)
+	}
+	found = []person{}
+	err = name.Query("peter").Exact().ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 3 {
+		return fmt.Errorf("exact search on fuzzy index incorrect: %v", found)
+	}
+
+	for batch := 0; batch < 11; batch++ { // postings of common trigrams exceed one page
+		err = dir.Write(func(tr *Transaction) {
+			for k := 0; k < 100; k++ {
+				dbPerson.Set(person{ID: 10 + batch*100 + k, Name: "Paul Smith"}).Check(tr)
+			}
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err = dbPerson.Set(person{ID: 5000, Name: "John Smith"}).Err()
+	if err != nil {
+		return err
+	}
+	found = []person{}
+	err = name.Search("jon smth").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 5000 {
+		return fmt.Errorf("fuzzy search beyond first page of postings incorrect: %v", found)
+	}
+	return nil
+}
+
+func testsSearchPhrase(dir *Directory) error {
+	type place struct {
+		ID   int    `stored:"id"`
+		Text string `stored:"text"`
+	}
+	pl := dir.Object("search_phrase_place", place{})
+	pl.Primary("id")
+	text := pl.IndexSearch("text")
+	dbPlace := pl.Done()
+	dbPlace.Clear()
+
+	places := []place{
+		{ID: 1, Text: "new york city"},
+		{ID: 2, Text: "york is not new"},
+		{ID: 3, Text: "new shiny big york hotel"},
+	}
+	for _, it := range places {
+		err := dbPlace.Set(it).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	found := []place{}
+	err := text.Search("new york").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 3 {
+		return fmt.Errorf("unquoted search should find all places: %v", found)
+	}
+	found = []place{}
+	err = text.Search(`"new york"`).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 1 {
+		return fmt.Errorf("phrase search incorrect: %v", found)
+	}
+	found = []place{}
+	err = text.Search("new NEAR/3 york").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 3 {
+		return fmt.Errorf("near/3 search incorrect: %v", found)
+	}
+	found = []place{}
+	err = text.Search("new NEAR/2 york").ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 1 {
+		return fmt.Errorf("near/2 search incorrect: %v", found)
+	}
+
+	err = dbPlace.Set(place{ID: 4, Text: "newark yorkshire"}).Err() // terms only as prefixes of longer words
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{"new york", `"new york"`, "new NEAR/3 york"} {
+		found = []place{}
+		err = text.Query(query).ScanAll(&found)
+		if err != nil {
+			return err
+		}
+		for _, it := range found {
+			if it.ID == 4 {
+				return fmt.Errorf("query %s should match whole words only: %v", query, found)
+			}
+		}
+	}
+	found = []place{}
+	err = text.Query("newark york").Prefix().ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 4 {
+		return fmt.Errorf("prefix search incorrect: %v", found)
+	}
+	found = []place{}
+	err = text.Search("newa yor").ScanAll(&found) // every unquoted word is a prefix
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 4 {
+		return fmt.Errorf("prefix search of all words incorrect: %v", found)
+	}
+	for _, query := range []string{`"new york"`, "new NEAR/3 york"} {
+		found = []place{}
+		err = text.Query(query).Prefix().ScanAll(&found)
+		if err != nil {
+			return err
+		}
+		for _, it := range found {
+			if it.ID == 4 {
+				return fmt.Errorf("prefix query %s should match quoted words exactly: %v", query, found)
+			}
+		}
+	}
+	return nil
+}
+
+func testsSearchSuggest(dir *Directory) error {
+	type song struct {
+		ID    int    `stored:"id"`
+		Title string `stored:"title"`
+	}
+	sg := dir.Object("search_suggest_song", song{})
+	sg.Primary("id")
+	title := sg.IndexSearch("title")
+	dbSong := sg.Done()
+	dbSong.Clear()
+
+	songs := []song{
+		{ID: 1, Title: "yellow submarine"},
+		{ID: 2, Title: "yesterday"},
+		{ID: 3, Title: "yesterday once more"},
+		{ID: 4, Title: "yes it is"},
+	}
+	for _, it := range songs {
+		err := dbSong.Set(it).Err()
+		if err != nil {
+			return err
+		}
+	}
+	err := dbSong.Delete(4).Err()
+	if err != nil {
+		return err
+	}
+
+	facets, err := title.Suggest("ye", 10).Facets()
+	if err != nil {
+		return err
+	}
+	if len(facets) != 2 || facets[0].Value != "yesterday" || facets[0].Count != 2 || facets[1].Value != "yellow" {
+		return fmt.Errorf("suggestions incorrect: %v", facets)
+	}
+	found := []song{}
+	err = title.SuggestObjects("yesterday", 1).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || (found[0].ID != 2 && found[0].ID != 3) {
+		return fmt.Errorf("suggested objects incorrect: %v", found)
+	}
+
+	words := []string{}
+	for k := 0; k <= searchSuggestScan; k++ { // more terms than suggestion ranks
+		word := []byte("paaaaa")
+		for n, rest := 5, k; rest > 0; n, rest = n-1, rest/26 {
+			word[n] = byte('a' + rest%26)
+		}
+		words = append(words, string(word))
+	}
+	for k := 0; k < 3; k++ {
+		part := words[k*len(words)/3 : (k+1)*len(words)/3]
+		err = dbSong.Set(song{ID: 10 + k, Title: "pazzz " + strings.Join(part, " ")}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	facets, err = title.Suggest("pa", 2).Facets()
+	if err != nil {
+		return err
+	}
+	if len(facets) != 2 || facets[0].Count != 1 || facets[1].Count != 1 {
+		return fmt.Errorf("suggestions should rank only first terms of the prefix: %v", facets)
+	}
+	facets, err = title.Suggest("paz", 1).Facets()
+	if err != nil {
+		return err
+	}
+	if len(facets) != 1 || facets[0].Value != "pazzz" || facets[0].Count != 3 {
+		return fmt.Errorf("suggestions of longer prefix incorrect: %v", facets)
+	}
+	return nil
+}
+
+func testsSearchPartition(dir *Directory) error {
+	type message struct {
+		ID     int    `stored:"id"`
+		ChatID int    `stored:"chat_id"`
+		Text   string `stored:"text"`
+	}
+	m := dir.Object("search_partition_message", message{})
+	m.Primary("id")
+	m.Index("chat_id")
+	text := m.IndexSearch("text").Partition("chat_id")
+	dbMessage := m.Done()
+	dbMessage.Clear()
+	n := dir.Object("search_partition_note", message{})
+	n.Primary("id")
+	noteText := n.IndexSearch("text").Partition("chat_id")
+	dbNote := n.Done()
+	dbNote.Clear()
+
+	messages := []message{
+		{ID: 1, ChatID: 1, Text: "hello world"},
+		{ID: 2, ChatID: 2, Text: "hello there"},
+		{ID: 3, ChatID: 1, Text: "bye world"},
+	}
+	for _, it := range messages {
+		err := dbMessage.Set(it).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := text.Search("hello").ScanAll(&[]message{})
+	if err != ErrPartitionRequired {
+		return fmt.Errorf("partition should be required, got: %v", err)
+	}
+	found := []message{}
+	err = text.Query("hello").In(2).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 || found[0].ID != 2 {
+		return fmt.Errorf("partitioned search incorrect: %v", found)
+	}
+
+	err = dbMessage.Set(message{ID: 2, ChatID: 1, Text: "hello there"}).Err() // moved to other chat
+	if err != nil {
+		return err
+	}
+	found = []message{}
+	err = text.Query("hello").In(1).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 2 {
+		return fmt.Errorf("moved message not found: %v", found)
+	}
+
+	err = text.ClearIn(1)
+	if err != nil {
+		return err
+	}
+	found = []message{}
+	err = text.Query("world").In(1).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 0 {
+		return fmt.Errorf("partition not cleared: %v", found)
+	}
+	err = text.ReindexIn(1)
+	if err != nil {
+		return err
+	}
+	found = []message{}
+	err = text.Query("world").In(1).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 2 {
+		return fmt.Errorf("partition not reindexed: %v", found)
+	}
+	err = dbMessage.SetField(&message{ID: 3, ChatID: 2}, "chat_id").Err()
+	if err != nil {
+		return err
+	}
+	for chatID, count := range map[int]int{1: 0, 2: 1} {
+		found = []message{}
+		err = text.Query("bye").In(chatID).ScanAll(&found)
+		if err != nil {
+			return err
+		}
+		if len(found) != count {
+			return fmt.Errorf("partition changed by SetField, chat %d has %v", chatID, found)
+		}
+	}
+
+	for _, it := range messages {
+		err = dbNote.Set(it).Err()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = dir.Cluster.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+		// posting of deleted object should be skipped by reindex
+		tr.Set(noteText.index.dir.Pack(searchKey(tuple.Tuple{int64(1)}, "world", tuple.Tuple{int64(99)})), []byte{})
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	err = noteText.ReindexIn(1) // object has no index of partition fields, keys of the partition are used
+	if err != nil {
+		return err
+	}
+	found = []message{}
+	err = noteText.Query("world").In(1).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 2 {
+		return fmt.Errorf("partition not reindexed by own keys: %v", found)
+	}
+	found = []message{}
+	err = noteText.Query("hello").In(2).ScanAll(&found)
+	if err != nil {
+		return err
+	}
+	if len(found) != 1 {
+		return fmt.Errorf("other partition changed by reindex: %v", found)
 	}
 	return nil
+}
+
+func testsSearchIndexGlobal(dir *Directory) error {
+	type user struct {
+		ID   int    `stored:"id"`
+		Name string `stored:"name"`
+	}
+	type channel struct {
+		ID          string `stored:"id"`
+		Title       string `stored:"title"`
+		Description string `stored:"description"`
+	}
+	global := dir.SearchIndex("tests_global")
+	u := dir.Object("search_global_user", user{})
+	u.Primary("id")
+	u.SearchIn(global, "name")
+	c := dir.Object("search_global_channel", channel{})
+	c.Primary("id")
+	c.SearchIn(global, "title", "description")
+	dbUser := u.Done()
+	dbChannel := c.Done()
+	dbUser.Clear()
+	dbChannel.Clear()
+
+	err := dbUser.Set(user{ID: 1, Name: "golang gopher"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbChannel.Set(channel{ID: "news", Title: "golang news", Description: "all about golang"}).Err()
+	if err != nil {
+		return err
+	}
+	err = dbChannel.Set(channel{ID: "cats", Title: "cats", Description: "no code here"}).Err()
+	if err != nil {
+		return err
+	}
+
+	hits, err := global.Query("golang").Hits()
+	if err != nil {
+		return err
+	}
+	if len(hits) != 2 {
+		return fmt.Errorf("global search should find 2 objects: %v", hits)
+	}
+	if hits[0].Object != "search_global_channel" || hits[0].Primary[0] != "news" {
+		return fmt.Errorf("channel with more occurrences should go first: %v", hits[0])
+	}
+	ch := channel{}
+	err = hits[0].Value.Scan(&ch)
+	if err != nil {
+		return err
+	}
+	if ch.Title != "golang news" {
+		return fmt.Errorf("channel decoded incorrectly: %v", ch)
+	}
+	usr := user{}
+	err = hits[1].Value.Scan(&usr)
+	if err != nil {
+		return err
+	}
+	if hits[1].Object != "search_global_user" || usr.ID != 1 {
+		return fmt.Errorf("user hit incorrect: %v", hits[1])
+	}
+	found := global.Search("gol") // prefix search of all attached objects
+	err = found.Err()
+	if err != nil {
+		return err
+	}
+	if found.Slice().Len() != 2 {
+		return fmt.Errorf("global prefix search should find 2 objects, found %d", found.Slice().Len())
+	}
 
+	err = dbChannel.Clear()
+	if err != nil {
+		return err
+	}
+	hits, err = global.Query("golang").Hits()
+	if err != nil {
+		return err
+	}
+	if len(hits) != 1 || hits[0].Object != "search_global_user" {
+		return fmt.Errorf("clear should remove only channel postings: %v", hits)
+	}
+	return nil
 }
 
 // TestsRun runs tests for STORED FoundationdDB layer
@@ -1130,5 +3344,32 @@ func TestsRun(db *Cluster) {
 	assert("geo_index", testsGeoIndex(dir))
 
 	assert("single_field", testsSingleField(dir))
+	assert("index_count", testsIndexCount(dir))
+	assert("collation", testsCollation(dir))
+	assert("unique_namespace", testsUniqueNamespace(dir))
+	assert("projection", testsProjection(dir))
+	assert("versionstamp_index", testsVersionstampIndex(dir))
+	assert("search_analyzer", testsSearchAnalyzer(dir))
+	assert("search_ranking", testsSearchRanking(dir))
+	assert("search_fuzzy", testsSearchFuzzy(dir))
+	assert("search_phrase", testsSearchPhrase(dir))
+	assert("search_suggest", testsSearchSuggest(dir))
+	assert("search_partition", testsSearchPartition(dir))
+	assert("search_global", testsSearchIndexGlobal(dir))
+	assert("geo_radius", testsGeoRadius(dir))
+	assert("geo_scan_paging", testsGeoScanPaging(dir))
+	assert("geo_nearest", testsGeoNearest(dir))
+	assert("geo_clusters", testsGeoClusters(dir))
+	assert("geo_partition", testsGeoPartition(dir))
+	assert("vector_index", testsVectorIndex(dir))
+	assert("context", testsContext(dir))
+	assert("tx_options", testsTxOptions(dir))
+	assert("commit_hooks", testsCommitHooks(dir))
+	assert("transaction_retry", testsTransactionRetry(dir))
+	assert("idempotency", testsIdempotency(dir))
+	assert("typed", testsTyped(dir))
+	assert("structured_errors", testsStructuredErrors(dir))
+	assert("futures", testsFutures(dir))
+	assert("loader", testsLoader(dir))
 	fmt.Println("elapsed", time.Since(start))
 }
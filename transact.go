@@ -0,0 +1,131 @@
+package stored
+
+import (
+	"context"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+)
+
+const fdbErrTimedOut = 1031 // transaction_timed_out
+
+// dbTransact will run write transaction, bound to the context and options if passed
+func dbTransact(ctx context.Context, options *TxOptions, db *fdb.Database, f func(fdb.Transaction) (interface{}, error)) (interface{}, error) {
+	if ctx == nil && options == nil {
+		return db.Transact(f)
+	}
+	return transactWith(ctx, options, *db, f)
+}
+
+// dbReadTransact will run read transaction, bound to the context and options if passed
+func dbReadTransact(ctx context.Context, options *TxOptions, db *fdb.Database, f func(fdb.ReadTransaction) (interface{}, error)) (interface{}, error) {
+	if ctx == nil && options == nil {
+		return db.ReadTransact(f)
+	}
+	return transactWith(ctx, options, *db, func(tr fdb.Transaction) (interface{}, error) {
+		return f(tr)
+	})
+}
+
+// transactWith works as fdb.Database.Transact but deadline of the context becomes timeout of the
+// transaction, transaction is cancelled once context is done and retry loop stops with ctx.Err().
+// Options set the retry policy, *RetryError is returned once retries are exhausted
+func transactWith(ctx context.Context, options *TxOptions, db fdb.Database, f func(fdb.Transaction) (interface{}, error)) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if options == nil {
+		options = &TxOptions{}
+	}
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	var deadline time.Time
+	if options.Timeout > 0 {
+		deadline = time.Now().Add(options.Timeout)
+	}
+	ctxDeadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		if !ctxDeadline.After(time.Now()) {
+			return nil, context.DeadlineExceeded
+		}
+		if deadline.IsZero() || ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		} else {
+			hasDeadline = false // options timeout comes first
+		}
+	}
+	tr, err := db.CreateTransaction()
+	if err != nil {
+		return nil, err
+	}
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			tr.Cancel()
+		case <-finished:
+		}
+	}()
+	for attempt := 1; ; attempt++ {
+		err = options.apply(tr, deadline)
+		if err != nil {
+			return nil, err
+		}
+		ret, err := transactAttempt(tr, f)
+		if err == nil {
+			return ret, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		fdbErr, ok := err.(fdb.Error)
+		if !ok { // logical error of the transaction
+			return nil, err
+		}
+		if fdbErr.Code == fdbErrTimedOut {
+			if hasDeadline {
+				return nil, context.DeadlineExceeded
+			}
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+		if options.RetryLimit > 0 && attempt > options.RetryLimit { // checked first, so OnError does not wait before giving up
+			return nil, &RetryError{Attempts: attempt, Err: fdbErr}
+		}
+		err = tr.OnError(fdbErr).Get()
+		if err != nil { // error is not retryable
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if options.Backoff != nil {
+			select {
+			case <-time.After(options.Backoff(attempt, fdbErr)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+// transactAttempt performs one try of the transaction, panicked fdb errors are returned same way
+// fdb.Database.Transact does
+func transactAttempt(tr fdb.Transaction, f func(fdb.Transaction) (interface{}, error)) (ret interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fdbErr, ok := r.(fdb.Error)
+			if !ok {
+				panic(r)
+			}
+			err = fdbErr
+		}
+	}()
+	ret, err = f(tr)
+	if err == nil {
+		err = tr.Commit().Get()
+	}
+	return
+}
@@ -1,6 +1,7 @@
 package stored
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
@@ -23,6 +24,17 @@ type Transaction struct {
 	started  bool
 	finish   bool
 	err      error
+	ctx      context.Context // transaction is bound to the context
+	options  *TxOptions
+	hooks    []transactionHook
+}
+
+// transactionHook is function called once transaction is committed or finally failed
+type transactionHook struct {
+	onCommit  func()
+	onFailure func(err error)
+	promise   *Promise // promise executed inside the transaction
+	err       error    // error of the promise, transaction could be committed anyway
 }
 
 func (t *Transaction) isReadOnly() bool {
@@ -42,16 +54,25 @@ func (t *Transaction) clear() {
 
 func (t *Transaction) initRead(tr fdb.ReadTransaction) {
 	t.tasks = []transactionTask{}
+	t.hooks = nil // callback will register hooks again
 	t.readTr = tr
 	t.started = true
+	t.finish = false // transaction is performed again on retry
+	t.err = nil
 }
 
 func (t *Transaction) initWrite(tr fdb.Transaction) {
 	t.tasks = []transactionTask{}
+	t.hooks = nil // callback will register hooks again
 	t.readTr = tr
+	if t.options != nil && t.options.Snapshot {
+		t.readTr = tr.Snapshot()
+	}
 	t.tr = tr
 	t.writable = true
 	t.started = true
+	t.finish = false // transaction is performed again on retry
+	t.err = nil
 }
 
 func (t *Transaction) setTr(promise *Promise) {
@@ -64,6 +85,10 @@ func (t *Transaction) transact() {
 		return
 	}
 	if t.started {
+		if t.ctx != nil && t.ctx.Err() != nil {
+			t.err = t.ctx.Err()
+			return
+		}
 		_, err := t.execute()
 		if t.err == nil {
 			t.err = err
@@ -75,24 +100,28 @@ func (t *Transaction) transact() {
 	db := t.db
 	var err error
 	if t.isReadOnly() {
-		_, err = db.ReadTransact(func(tr fdb.ReadTransaction) (interface{}, error) {
+		_, err = dbReadTransact(t.ctx, t.options, db, func(tr fdb.ReadTransaction) (interface{}, error) {
 			t.clear()
 			t.readTr = tr.Snapshot()
 			return t.execute()
 		})
 		t.confirm()
 	} else {
-		_, err = db.Transact(func(tr fdb.Transaction) (ret interface{}, err error) {
+		_, err = dbTransact(t.ctx, t.options, db, func(tr fdb.Transaction) (ret interface{}, err error) {
 			t.clear()
 			t.tr = tr
 			t.readTr = tr
+			if t.options != nil && t.options.Snapshot {
+				t.readTr = tr.Snapshot()
+			}
 			return t.execute()
 		})
 		t.confirm()
 	}
-	if t.err == nil {
+	if t.err == nil || ((t.ctx != nil || t.options != nil) && err != nil) {
 		t.err = err
 	}
+	t.finished(t.err)
 }
 
 // will set all promises as confirmed
@@ -106,7 +135,7 @@ func (t *Transaction) execute() (ret interface{}, err error) {
 	chains := make([]Chain, len(t.tasks))
 	for i, task := range t.tasks {
 		t.setTr(task.promise)
-		chains[i] = task.promise.chain
+		chains[i] = task.promise.getChain()
 	}
 	t.finish = true
 	next := true
@@ -134,7 +163,7 @@ func (t *Transaction) execute() (ret interface{}, err error) {
 					promise.after = nil
 					t.setTr(after)
 					t.tasks = append(t.tasks, transactionTask{promise: after})
-					chains = append(chains, after.chain)
+					chains = append(chains, after.getChain())
 					next = true
 				}
 				if task.onDone != nil {
@@ -156,6 +185,61 @@ func (t *Transaction) Err() error {
 	return t.err
 }
 
+// ErrCtx will perform all promises within transaction bound to the context and return err if any
+// of them failed, ctx.Err() is returned once context is done
+func (t *Transaction) ErrCtx(ctx context.Context) error {
+	t.ctx = ctx
+	t.transact()
+	return t.err
+}
+
+// OnCommit will register function called once the transaction is committed, hooks registered inside
+// transaction callback are reset on every retry, so function is called only once
+func (t *Transaction) OnCommit(fn func()) {
+	t.hooks = append(t.hooks, transactionHook{onCommit: fn})
+}
+
+// OnFailure will register function called once the transaction finally failed, after all the retries
+func (t *Transaction) OnFailure(fn func(err error)) {
+	t.hooks = append(t.hooks, transactionHook{onFailure: fn})
+}
+
+// attachHooks will postpone hooks of the promise executed inside the transaction
+func (t *Transaction) attachHooks(p *Promise, err error) {
+	if p.onCommit != nil || p.onFailure != nil {
+		t.hooks = append(t.hooks, transactionHook{promise: p, err: err})
+	}
+}
+
+// finished will call hooks of the transaction and its promises once the transaction is committed
+// or finally failed
+func (t *Transaction) finished(err error) {
+	hooks := append([]transactionHook{}, t.hooks...)
+	for _, task := range t.tasks {
+		if task.promise.onCommit != nil || task.promise.onFailure != nil {
+			hooks = append(hooks, transactionHook{promise: task.promise, err: task.promise.err})
+		}
+	}
+	for _, hook := range hooks {
+		switch {
+		case hook.promise != nil && err != nil:
+			hook.promise.finished(err)
+		case hook.promise != nil:
+			hook.promise.finished(hook.err)
+		case err == nil && hook.onCommit != nil:
+			hook.onCommit()
+		case err != nil && hook.onFailure != nil:
+			hook.onFailure(err)
+		}
+	}
+}
+
+// Options sets how the transaction is performed and retried, should be called before Err
+func (t *Transaction) Options(options TxOptions) *Transaction {
+	t.options = &options
+	return t
+}
+
 // Fail will set the transaction error, so
 func (t *Transaction) Fail(err error) {
 	t.err = err
@@ -0,0 +1,84 @@
+package stored
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+)
+
+// TxPriority is priority of the transaction inside FoundationDB
+type TxPriority int
+
+const (
+	// TxPriorityDefault is default priority of the transaction
+	TxPriorityDefault TxPriority = iota
+	// TxPriorityBatch is the priority for background jobs, throttled first when cluster is saturated
+	TxPriorityBatch
+	// TxPrioritySystemImmediate is the highest priority, should be used with care
+	TxPrioritySystemImmediate
+)
+
+// TxOptions describes how transactions are performed and retried, could be set for the Directory,
+// for the Transaction or for the Promise
+type TxOptions struct {
+	RetryLimit      int           // maximum number of retries, zero means retry until success or timeout
+	Timeout         time.Duration // maximum time of the transaction including retries
+	MaxRetryDelay   time.Duration // maximum delay between retries FoundationDB will wait
+	Priority        TxPriority
+	CausalReadRisky bool // read version is not confirmed, reads are faster but could be stale on failures
+	Snapshot        bool // reads of queries in write transactions do not cause conflicts
+	// Backoff is called before each retry and return additional delay before the next attempt
+	Backoff func(attempt int, err error) time.Duration
+}
+
+// RetryError is returned when transaction was not committed because retries were exhausted or
+// the transaction timed out, unlike logical errors of promises the same call could succeed later
+type RetryError struct {
+	Attempts int   // number of attempts performed
+	Err      error // last error returned by FoundationDB
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("transaction failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap return last error of FoundationDB
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// apply will set options for the attempt of the transaction, timeout is what remains till deadline
+func (o *TxOptions) apply(tr fdb.Transaction, deadline time.Time) error {
+	options := tr.Options()
+	if !deadline.IsZero() {
+		timeout := int64(time.Until(deadline) / time.Millisecond)
+		if timeout < 1 {
+			timeout = 1 // transaction will be timed out
+		}
+		err := options.SetTimeout(timeout)
+		if err != nil {
+			return err
+		}
+	}
+	if o.MaxRetryDelay > 0 {
+		err := options.SetMaxRetryDelay(int64(o.MaxRetryDelay / time.Millisecond))
+		if err != nil {
+			return err
+		}
+	}
+	switch o.Priority {
+	case TxPriorityBatch:
+		if err := options.SetPriorityBatch(); err != nil {
+			return err
+		}
+	case TxPrioritySystemImmediate:
+		if err := options.SetPrioritySystemImmediate(); err != nil {
+			return err
+		}
+	}
+	if o.CausalReadRisky {
+		return options.SetCausalReadRisky()
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package stored
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+const typedPageSize = 100 // number of objects fetched by one transaction of TypedIterator
+
+// TypedObject is type safe layer over the Object for the scheme struct T, values which do not match
+// the scheme are returned as errors instead of panics
+type TypedObject[T any] struct {
+	object *Object
+}
+
+// Typed will finish building of the object and return type safe layer over it, T should be the
+// struct object was declared with
+func Typed[T any](ob *ObjectBuilder) *TypedObject[T] {
+	object := ob.Done()
+	if reflect.TypeOf((*T)(nil)).Elem() != object.reflectType {
+		ob.panic("typed object should use scheme type " + object.reflectType.String())
+	}
+	return &TypedObject[T]{object: object}
+}
+
+// Object return underlying object
+func (to *TypedObject[T]) Object() *Object {
+	return to.object
+}
+
+// Get fetch object using values of primary fields
+func (to *TypedObject[T]) Get(ctx context.Context, primary ...interface{}) (T, error) {
+	var value T
+	err := to.object.fillFields(&value, to.object.primaryFields, primary)
+	if err != nil {
+		return value, err
+	}
+	err = to.object.Get(&value).ErrCtx(ctx)
+	return value, err
+}
+
+// GetFuture return future of the object fetched using values of primary fields, so it could be
+// fetched together with other futures
+func (to *TypedObject[T]) GetFuture(primary ...interface{}) *Future[T] {
+	value := new(T)
+	err := to.object.fillFields(value, to.object.primaryFields, primary)
+	if err != nil {
+		p := to.object.promiseErr()
+		p.doRead(func() Chain {
+			return p.fail(err)
+		})
+		return FutureOf(p, value)
+	}
+	return FutureOf(to.object.Get(value), value)
+}
+
+// GetBy fetch one object using index by name or name of the index field, values are set to the fields
+// of the index in order
+func (to *TypedObject[T]) GetBy(ctx context.Context, indexName string, values ...interface{}) (T, error) {
+	var value T
+	index, ok := to.object.indexes[indexName]
+	if !ok {
+		return value, fmt.Errorf("object «%s»: index «%s» is undefined", to.object.name, indexName)
+	}
+	err := to.object.fillFields(&value, index.fields, values)
+	if err != nil {
+		return value, err
+	}
+	err = to.object.GetBy(&value, indexName).ErrCtx(ctx)
+	return value, err
+}
+
+// Set writes the object
+func (to *TypedObject[T]) Set(value T) *PromiseErr {
+	return to.object.Set(&value)
+}
+
+// Add writes new object, generated primary fields are set to the passed value
+func (to *TypedObject[T]) Add(value *T) *PromiseErr {
+	return to.object.Add(value)
+}
+
+// Delete removes object using values of primary fields
+func (to *TypedObject[T]) Delete(primary ...interface{}) *PromiseErr {
+	var value T
+	err := to.object.fillFields(&value, to.object.primaryFields, primary)
+	if err != nil {
+		p := to.object.promiseErr()
+		p.do(func() Chain {
+			return p.fail(err)
+		})
+		return p
+	}
+	return to.object.Delete(&value)
+}
+
+// List return iterator over all the objects in order of primary key, objects are fetched by pages
+// each in separate transaction
+func (to *TypedObject[T]) List() *TypedIterator[T] {
+	return &TypedIterator[T]{object: to.object}
+}
+
+// TypedIterator goes through objects of TypedObject
+//
+//	for it := dbUser.List(); it.Next(); {
+//	  user := it.Value()
+//	}
+//	err := it.Err()
+type TypedIterator[T any] struct {
+	object *Object
+	page   []T
+	pos    int
+	last   tuple.Tuple // primary of the last fetched object
+	done   bool
+	err    error
+}
+
+// Next will move iterator to the next object, return false once all objects are passed or fetch failed
+func (it *TypedIterator[T]) Next() bool {
+	if it.pos < len(it.page) {
+		it.pos++
+	}
+	for it.pos == len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	return true
+}
+
+// fetch will load next page of objects, first object of the page is the last one of previous page
+func (it *TypedIterator[T]) fetch() {
+	limit := typedPageSize
+	query := it.object.ListAll()
+	if it.last != nil {
+		limit++
+		from := []interface{}{}
+		for _, element := range it.last {
+			from = append(from, element)
+		}
+		query.From(from...)
+	}
+	page := []T{}
+	it.err = query.Limit(limit).ScanAll(&page)
+	if it.err != nil {
+		return
+	}
+	it.done = len(page) < limit
+	if len(page) == 0 {
+		it.page, it.pos = page, 0
+		return
+	}
+	if it.last != nil && reflect.DeepEqual(it.object.getPrimaryTuple(&page[0]), it.last) {
+		page = page[1:]
+	}
+	if len(page) != 0 {
+		it.last = it.object.getPrimaryTuple(&page[len(page)-1])
+	}
+	it.page, it.pos = page, 0
+}
+
+// Value return current object of the iterator
+func (it *TypedIterator[T]) Value() T {
+	if it.pos >= len(it.page) {
+		var empty T
+		return empty
+	}
+	return it.page[it.pos]
+}
+
+// Err return error if fetch of the objects failed
+func (it *TypedIterator[T]) Err() error {
+	return it.err
+}
+
+// fillFields will set values to the fields of object pointer, returns an error if number or types
+// of values do not match the fields
+func (o *Object) fillFields(objectPtr interface{}, fields []*Field, values []interface{}) error {
+	if len(values) != len(fields) {
+		names := []string{}
+		for _, field := range fields {
+			names = append(names, field.Name)
+		}
+		return fmt.Errorf("object «%s»: %d values passed for fields %s", o.name, len(values), strings.Join(names, ","))
+	}
+	object := reflect.ValueOf(objectPtr).Elem()
+	for k, field := range fields {
+		fieldValue := object.Field(field.Num)
+		value := reflect.ValueOf(values[k])
+		switch {
+		case !value.IsValid():
+			return fmt.Errorf("object «%s»: nil value passed for field «%s»", o.name, field.Name)
+		case value.Type().AssignableTo(fieldValue.Type()):
+			fieldValue.Set(value)
+		case typedNumeric(value.Kind()) && typedNumeric(fieldValue.Kind()):
+			fieldValue.Set(value.Convert(fieldValue.Type()))
+		default:
+			return fmt.Errorf("object «%s»: field «%s» is %s, %s passed", o.name, field.Name, fieldValue.Type(), value.Type())
+		}
+	}
+	return nil
+}
+
+// typedNumeric return true for kinds values could be converted between
+func typedNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// TypedRelation is type safe layer over the Relation between host objects H and client objects C
+type TypedRelation[H any, C any] struct {
+	relation *Relation
+}
+
+// TypedN2N will return type safe layer over the relation, H and C should be the structs host and
+// client objects were declared with
+func TypedN2N[H any, C any](relation *Relation) *TypedRelation[H, C] {
+	if reflect.TypeOf((*H)(nil)).Elem() != relation.host.reflectType {
+		relation.panic("typed relation should use host type " + relation.host.reflectType.String())
+	}
+	if reflect.TypeOf((*C)(nil)).Elem() != relation.client.reflectType {
+		relation.panic("typed relation should use client type " + relation.client.reflectType.String())
+	}
+	return &TypedRelation[H, C]{relation: relation}
+}
+
+// Relation return underlying relation
+func (tr *TypedRelation[H, C]) Relation() *Relation {
+	return tr.relation
+}
+
+// Add writes new relation between objects, fails with ErrAlreadyExist if relation is set
+func (tr *TypedRelation[H, C]) Add(host H, client C) *PromiseErr {
+	return tr.relation.Add(&host, &client)
+}
+
+// Set writes relation between objects
+func (tr *TypedRelation[H, C]) Set(host H, client C) *PromiseErr {
+	return tr.relation.Set(&host, &client)
+}
+
+// Delete removes relation between objects
+func (tr *TypedRelation[H, C]) Delete(host H, client C) *PromiseErr {
+	return tr.relation.Delete(&host, &client)
+}
+
+// Check return true if relation between objects is set
+func (tr *TypedRelation[H, C]) Check(ctx context.Context, host H, client C) (bool, error) {
+	p := tr.relation.Check(&host, &client)
+	p.ctx = ctx
+	return p.Bool()
+}
+
+// GetClients fetch up to limit client objects of the host, zero limit fetches all of them
+func (tr *TypedRelation[H, C]) GetClients(ctx context.Context, host H, limit int) ([]C, error) {
+	clients := []C{}
+	err := tr.relation.GetClients(&host, nil).Limit(limit).ScanAllCtx(ctx, &clients)
+	return clients, err
+}
+
+// GetHosts fetch up to limit host objects of the client, zero limit fetches all of them
+func (tr *TypedRelation[H, C]) GetHosts(ctx context.Context, client C, limit int) ([]H, error) {
+	hosts := []H{}
+	err := tr.relation.GetHosts(&client, nil).Limit(limit).ScanAllCtx(ctx, &hosts)
+	return hosts, err
+}
@@ -0,0 +1,55 @@
+package stored
+
+import (
+	"bytes"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/directory"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+// UniqueNamespace is the set of unique values shared between several objects, for example login
+// should be unique across users, organizations and bots
+type UniqueNamespace struct {
+	name string
+	dir  directory.DirectorySubspace
+}
+
+// Name return name of the namespace
+func (n *UniqueNamespace) Name() string {
+	return n.name
+}
+
+// ownerValue will return value stored inside the namespace key, containing object name and primary
+func (n *UniqueNamespace) ownerValue(object *Object, primaryTuple tuple.Tuple) []byte {
+	owner := tuple.Tuple{object.name}
+	return append(owner, primaryTuple...).Pack()
+}
+
+// parseOwner will return object name and primary of the owner of the value
+func (n *UniqueNamespace) parseOwner(value []byte) (string, tuple.Tuple, error) {
+	owner, err := tuple.Unpack(value)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(owner) < 1 {
+		return "", nil, ErrDataCorrupt
+	}
+	objectName, ok := owner[0].(string)
+	if !ok {
+		return "", nil, ErrDataCorrupt
+	}
+	return objectName, owner[1:], nil
+}
+
+// clearObject will remove all values owned by the object, namespace is read by batches of
+// separate transactions since it could be much bigger than values of one object
+func (n *UniqueNamespace) clearObject(object *Object) error {
+	prefix := tuple.Tuple{object.name}.Pack()
+	return clearPaged(object.db, n.dir, func(tr fdb.Transaction, row fdb.KeyValue) error {
+		if bytes.HasPrefix(row.Value, prefix) {
+			tr.Clear(row.Key)
+		}
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"time"
@@ -21,6 +22,80 @@ var ErrDataCorrupt = errors.New("Data corrupt")
 // ErrAlreadyExist Object with this primary index or one of unique indexes already
 var ErrAlreadyExist = errors.New("This object already exist")
 
+// ErrPartitionRequired partitioned search or geo index is queried without partition values
+var ErrPartitionRequired = errors.New("Index is partitioned, partition values should be set using In or Where")
+
+// ErrVersionstampPending object of versionstamp index is moved or deleted inside the transaction added it
+var ErrVersionstampPending = errors.New("Versionstamp of the object is not known until commit")
+
+// UniqueViolation is returned when the value of unique index is already owned by another object,
+// errors.Is(err, ErrAlreadyExist) reports true for it
+type UniqueViolation struct {
+	Object          string      // name of the object owning the value
+	Index           string      // name of the index or unique namespace
+	Value           tuple.Tuple // index key of the value
+	ExistingPrimary tuple.Tuple // primary of the object owning the value
+}
+
+func (e *UniqueViolation) Error() string {
+	return fmt.Sprintf("%s: value %v of «%s» is owned by object «%s» %v", ErrAlreadyExist.Error(), e.Value, e.Index, e.Object, e.ExistingPrimary)
+}
+
+// Is makes UniqueViolation match ErrAlreadyExist
+func (e *UniqueViolation) Is(target error) bool {
+	return target == ErrAlreadyExist
+}
+
+// DuplicateError is returned when the object or relation with the same primary already exists,
+// errors.Is(err, ErrAlreadyExist) reports true for it
+type DuplicateError struct {
+	Object  string      // name of the object, or of objects of the relation
+	Primary tuple.Tuple // primary of the existing object
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s: object «%s» %v", ErrAlreadyExist.Error(), e.Object, e.Primary)
+}
+
+// Is makes DuplicateError match ErrAlreadyExist
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrAlreadyExist
+}
+
+// NotFoundError is returned when the object is not found, errors.Is(err, ErrNotFound) reports true for it
+type NotFoundError struct {
+	Object  string      // name of the object, or of objects of the relation
+	Primary tuple.Tuple // primary of the object, empty if object was fetched by index
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Primary) == 0 {
+		return fmt.Sprintf("%s: object «%s»", ErrNotFound.Error(), e.Object)
+	}
+	return fmt.Sprintf("%s: object «%s» %v", ErrNotFound.Error(), e.Object, e.Primary)
+}
+
+// Is makes NotFoundError match ErrNotFound
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// CorruptionError is returned when stored data could not be decoded, errors.Is(err, ErrDataCorrupt)
+// reports true for it
+type CorruptionError struct {
+	Key    fdb.Key // key of the corrupted data
+	Reason string
+}
+
+func (e *CorruptionError) Error() string {
+	return fmt.Sprintf("%s: %s, key %s", ErrDataCorrupt.Error(), e.Reason, e.Key)
+}
+
+// Is makes CorruptionError match ErrDataCorrupt
+func (e *CorruptionError) Is(target error) bool {
+	return target == ErrDataCorrupt
+}
+
 // ErrSkip returned in cases when it is necessary to skip operation without cancelling
 // underlying transactions
 var ErrSkip = errors.New("Operation was skipped")
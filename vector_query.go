@@ -0,0 +1,176 @@
+package stored
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
+)
+
+// VectorHit is the object found by vector query with its similarity
+type VectorHit struct {
+	Score   float64     // similarity with the query vector, bigger is more similar
+	Primary tuple.Tuple // primary key of the object found
+	Value   *Value
+}
+
+// VectorQuery is vector index query builder
+type VectorQuery struct {
+	index  *Index
+	vector []float32
+	k      int
+	probes int  // number of lists nearest to the vector to read
+	exact  bool // compare with all the vectors of the index
+}
+
+// Probes sets number of lists nearest to the vector to read, more lists give better recall
+func (vq *VectorQuery) Probes(probes int) *VectorQuery {
+	vq.probes = probes
+	return vq
+}
+
+// Exact will compare the vector with all the vectors of the index, suitable for small objects
+func (vq *VectorQuery) Exact() *VectorQuery {
+	vq.exact = true
+	return vq
+}
+
+// Hits will return found objects with scores, most similar first
+func (vq *VectorQuery) Hits() ([]VectorHit, error) {
+	return vq.PromiseHits().Hits()
+}
+
+// PromiseHits will return promise of found objects with scores
+func (vq *VectorQuery) PromiseHits() *PromiseVectorHits {
+	p := vq.index.object.promiseVectorHits()
+	p.doRead(func() Chain {
+		return vq.run(&p.Promise, func(hits []VectorHit) Chain {
+			return p.done(hits)
+		})
+	})
+	return p
+}
+
+// Promise will return promise of found objects, most similar first
+func (vq *VectorQuery) Promise() *PromiseSlice {
+	p := vq.index.object.promiseSlice()
+	p.doRead(func() Chain {
+		return vq.run(&p.Promise, p.doneVectorHits)
+	})
+	return p
+}
+
+// ScanAll will fill slice with found objects, most similar first
+func (vq *VectorQuery) ScanAll(slicePointer interface{}) error {
+	return vq.Promise().ScanAll(slicePointer)
+}
+
+// Slice will return slice of found objects, most similar first
+func (vq *VectorQuery) Slice() *Slice {
+	return vq.Promise().Slice()
+}
+
+// Do will return promise of the query attached to the transaction
+func (vq *VectorQuery) Do(tr *Transaction) *PromiseSlice {
+	return vq.Promise().Do(tr)
+}
+
+// TryAll performs query within the transaction, see PromiseSlice.TryAll
+func (vq *VectorQuery) TryAll(tr *Transaction, slicePointer interface{}) {
+	vq.Promise().TryAll(tr, slicePointer)
+}
+
+// CheckAll performs query within the transaction, see PromiseSlice.CheckAll
+func (vq *VectorQuery) CheckAll(tr *Transaction, slicePointer interface{}) {
+	vq.Promise().CheckAll(tr, slicePointer)
+}
+
+// run will choose lists of centroids nearest to the vector and rank vectors stored inside
+func (vq *VectorQuery) run(p *Promise, finish func(hits []VectorHit) Chain) Chain {
+	i := vq.index
+	if len(vq.vector) != i.vectorDims {
+		return p.fail(fmt.Errorf("vector has %d dimensions instead of %d", len(vq.vector), i.vectorDims))
+	}
+	if vq.exact {
+		return vq.scan(p, []fdb.Range{i.dir}, finish)
+	}
+	centroidsGet := i.needCentroids(p.readTr)
+	return func() Chain {
+		centroids, err := centroidsGet()
+		if err != nil {
+			return p.fail(err)
+		}
+		centroids = append([]vectorCentroid{}, centroids...) // cached centroids are shared
+		scores := map[int64]float64{}
+		for _, centroid := range centroids {
+			scores[centroid.list] = i.vectorMetric.score(vq.vector, centroid.vector)
+		}
+		sort.SliceStable(centroids, func(a, b int) bool {
+			return scores[centroids[a].list] > scores[centroids[b].list]
+		})
+		if vq.probes > 0 && len(centroids) > vq.probes {
+			centroids = centroids[:vq.probes]
+		}
+		ranges := []fdb.Range{}
+		for _, centroid := range centroids {
+			ranges = append(ranges, i.dir.Sub(centroid.list))
+		}
+		return vq.scan(p, ranges, finish)
+	}
+}
+
+// scan will read vectors of the ranges, objects of k most similar vectors are fetched
+func (vq *VectorQuery) scan(p *Promise, ranges []fdb.Range, finish func(hits []VectorHit) Chain) Chain {
+	i := vq.index
+	rangeResults := []fdb.RangeResult{}
+	for _, r := range ranges {
+		rangeResults = append(rangeResults, p.readTr.GetRange(r, fdb.RangeOptions{
+			Mode: fdb.StreamingModeWantAll,
+		}))
+	}
+	return func() Chain {
+		hits := []VectorHit{}
+		for _, res := range rangeResults {
+			rows, err := res.GetSliceWithError()
+			if err != nil {
+				return p.fail(err)
+			}
+			for _, row := range rows {
+				key, err := i.dir.Unpack(row.Key)
+				if err != nil {
+					return p.fail(err)
+				}
+				if len(key) < 2 {
+					continue
+				}
+				hits = append(hits, VectorHit{
+					Score:   i.vectorMetric.score(vq.vector, vectorDecode(row.Value)),
+					Primary: key[1:],
+				})
+			}
+		}
+		sort.SliceStable(hits, func(a, b int) bool {
+			return hits[a].Score > hits[b].Score
+		})
+		if vq.k > 0 && len(hits) > vq.k {
+			hits = hits[:vq.k]
+		}
+		need := make([]*needObject, len(hits))
+		for k, hit := range hits {
+			need[k] = i.object.need(p.readTr, i.object.sub(hit.Primary))
+		}
+		return func() Chain {
+			found := []VectorHit{}
+			for k, n := range need {
+				val, err := n.fetch()
+				if err != nil {
+					continue
+				}
+				hits[k].Value = val
+				found = append(found, hits[k])
+			}
+			return finish(found)
+		}
+	}
+}